@@ -45,9 +45,10 @@ func (c *Consumer) Stop() {
 
 func (c *Consumer) handle() {
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for msg := range c.queue {
-		c.handler(msg)
+		logger.Protect(c.logger, func() { c.handler(msg) })
 	}
 }
 
@@ -85,6 +86,7 @@ func (c *Consumer) consume(addr string) (err error) {
 func (c *Consumer) receive(addr string) {
 	defer close(c.queue)
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for {
 		select {
@@ -98,6 +98,7 @@ func (c *Producer) put(addr string, msg *Message) (err error) {
 
 func (c *Producer) send(addr string) {
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for msg := range c.queue {
 		if err := c.put(addr, msg); err != nil {
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+func keyString(key interface{}) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case fmt.Stringer:
+		return k.String()
+	default:
+		return fmt.Sprint(k)
+	}
+}
+
+// DeletePrefix removes every entry whose key, in string form, starts with prefix and returns how many were removed.
+func (c *Cache[K, V]) DeletePrefix(prefix string) (count int) {
+	match := func(key K) bool {
+		return strings.HasPrefix(keyString(key), prefix)
+	}
+
+	for _, s := range c.shards {
+		count += s.deleteFunc(match)
+	}
+
+	return
+}
+
+// DeleteByTag removes every entry stored with the tag through SetWithTags and returns how many were removed.
+func (c *Cache[K, V]) DeleteByTag(tag string) (count int) {
+	for _, s := range c.shards {
+		count += s.deleteTag(tag)
+	}
+
+	return
+}
+
+// Range calls fn for every live entry until it returns false. Entries are copied one shard at a time,
+// so fn may use the cache, and changes made during the iteration may or may not be seen.
+func (c *Cache[K, V]) Range(fn func(key K, value V) bool) {
+	for _, s := range c.shards {
+		keys, values := s.live(time.Now().UnixNano())
+
+		for i, key := range keys {
+			if !fn(key, values[i]) {
+				return
+			}
+		}
+	}
+}
+
+func (c *Cache[K, V]) Len() (count int) {
+	nowTime := time.Now().UnixNano()
+
+	for _, s := range c.shards {
+		count += s.count(nowTime)
+	}
+
+	return
+}
+
+func (c *Cache[K, V]) Clear() {
+	for _, s := range c.shards {
+		s.deleteFunc(func(K) bool { return true })
+	}
+}
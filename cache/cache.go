@@ -1,114 +1,284 @@
 package cache
 
 import (
+	"container/list"
+	"context"
+	"fmt"
+	"hash/fnv"
 	"sync"
 	"time"
+
+	"github.com/marsmay/golib/coder"
 )
 
 const (
-	initSize = 4096
-	interval = 10
+	initSize     = 4096
+	interval     = 10
+	shardCount   = 16
+	minShardSize = 64
+	fnvOffset    = 14695981039346656037
+	fnvPrime     = 1099511628211
 )
 
-type Item struct {
-	data     interface{}
+type Config struct {
+	MaxEntries      int           `toml:"max_entries" json:"max_entries"`
+	MaxCost         int64         `toml:"max_cost" json:"max_cost"`
+	Policy          int           `toml:"policy" json:"policy"`
+	StaleTtl        time.Duration `toml:"stale_ttl" json:"stale_ttl"`
+	NegativeTtl     time.Duration `toml:"negative_ttl" json:"negative_ttl"`
+	Shards          int           `toml:"shards" json:"shards"`
+	CleanupInterval time.Duration `toml:"cleanup_interval" json:"cleanup_interval"`
+
+	// SnapshotFile is restored by New and written every SnapshotInterval and on Close.
+	SnapshotFile     string        `toml:"snapshot_file" json:"snapshot_file"`
+	SnapshotInterval time.Duration `toml:"snapshot_interval" json:"snapshot_interval"`
+	Coder            coder.ICoder  `toml:"-" json:"-"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Policy:          PolicyLRU,
+		Shards:          shardCount,
+		CleanupInterval: interval * 1000,
+	}
+}
+
+type Item[K comparable, V any] struct {
+	key      K
+	data     V
+	cost     int64
 	createAt int64
 	expire   int64
+	negative bool
+	tags     []string
+	element  *list.Element
+	index    int
+	freq     uint64
+	tick     uint64
+}
+
+func (i *Item[K, V]) expired(nowTime int64) bool {
+	return i.expire > 0 && nowTime >= i.createAt+i.expire
 }
 
-type Cache struct {
-	sync.RWMutex
-	items map[string]*Item
-	timer *time.Ticker
+type Cache[K comparable, V any] struct {
+	conf     *Config
+	shards   []*shard[K, V]
+	timer    *time.Ticker
+	loadLock sync.Mutex
+	calls    map[K]*call[V]
+	recorder *recorder[K, V]
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       *sync.WaitGroup
+
+	snapshotTimer *time.Ticker
+	snapshotLock  sync.Mutex
+	snapshotErr   error
 }
 
-func New() *Cache {
-	c := &Cache{
-		items: make(map[string]*Item, initSize),
-		timer: time.NewTicker(interval * time.Second),
+// New creates a cache whose MaxEntries and MaxCost bounds are split evenly across shards,
+// so eviction order is exact within a shard and approximate across the whole cache.
+func New[K comparable, V any](conf *Config) *Cache[K, V] {
+	if conf == nil {
+		conf = DefaultConfig()
+	}
+
+	count := conf.Shards
+
+	if count <= 0 {
+		count = shardCount
+	}
+
+	for count > 1 && conf.MaxEntries > 0 && conf.MaxEntries/count < minShardSize {
+		count /= 2
+	}
+
+	cleanupInterval := conf.CleanupInterval * time.Millisecond
+
+	if cleanupInterval <= 0 {
+		cleanupInterval = interval * time.Second
+	}
+
+	c := &Cache[K, V]{
+		conf:     conf,
+		shards:   make([]*shard[K, V], count),
+		timer:    time.NewTicker(cleanupInterval),
+		calls:    make(map[K]*call[V], 16),
+		recorder: &recorder[K, V]{},
+		wg:       &sync.WaitGroup{},
+	}
+
+	for i := range c.shards {
+		maxEntries, maxCost := conf.MaxEntries, conf.MaxCost
+
+		if maxEntries > 0 {
+			maxEntries = (maxEntries + count - 1) / count
+		}
+
+		if maxCost > 0 {
+			maxCost = (maxCost + int64(count) - 1) / int64(count)
+		}
+
+		c.shards[i] = newShard[K, V](conf, maxEntries, maxCost, c.recorder)
+	}
+
+	if conf.SnapshotFile != "" {
+		c.restore()
+
+		if conf.SnapshotInterval > 0 {
+			c.snapshotTimer = time.NewTicker(conf.SnapshotInterval * time.Millisecond)
+		}
 	}
 
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	c.wg.Add(1)
 	go c.run()
+
 	return c
 }
 
-func (c *Cache) Get(key string) (interface{}, bool) {
-	c.RLock()
-	defer c.RUnlock()
+// hashKey switches on a copy of key that doesn't escape, so hashing common key types doesn't allocate.
+func hashKey[K comparable](key K) uint64 {
+	switch k := any(key).(type) {
+	case string:
+		// inline FNV-1a, fnv.New64a would allocate on every lookup
+		h := uint64(fnvOffset)
 
-	if item, ok := c.items[key]; ok && (item.expire == 0 || time.Now().Unix() < item.createAt+item.expire) {
-		return item.data, true
-	}
+		for i := 0; i < len(k); i++ {
+			h ^= uint64(k[i])
+			h *= fnvPrime
+		}
 
-	return nil, false
+		return h
+	case int:
+		return mix(uint64(k))
+	case int32:
+		return mix(uint64(k))
+	case int64:
+		return mix(uint64(k))
+	case uint:
+		return mix(uint64(k))
+	case uint32:
+		return mix(uint64(k))
+	case uint64:
+		return mix(k)
+	default:
+		return hashAny(key)
+	}
 }
 
-func (c *Cache) Set(key string, value interface{}, expire time.Duration) {
-	c.Lock()
-	defer c.Unlock()
+func hashAny(key interface{}) uint64 {
+	h := fnv.New64a()
+	_, _ = fmt.Fprint(h, key)
+	return h.Sum64()
+}
 
-	item := &Item{data: value, createAt: time.Now().Unix()}
+// mix spreads sequential integer keys over all shards (splitmix64 finalizer).
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
 
-	if expire > 0 {
-		item.expire = int64(expire / time.Second)
+func (c *Cache[K, V]) shard(key K) *shard[K, V] {
+	if len(c.shards) == 1 {
+		return c.shards[0]
 	}
 
-	c.items[key] = item
+	return c.shards[hashKey(key)%uint64(len(c.shards))]
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	return c.shard(key).get(key, time.Now().UnixNano())
 }
 
-func (c *Cache) Expire(key string, expire time.Duration) {
-	c.Lock()
-	defer c.Unlock()
+func (c *Cache[K, V]) Set(key K, value V, expire time.Duration) {
+	c.SetWithCost(key, value, 0, expire)
+}
 
-	if item, ok := c.items[key]; ok {
-		if expire > 0 {
-			item.expire = int64(expire / time.Second)
-		} else {
-			item.expire = 0
-		}
+// SetWithCost stores the value with its cost (e.g. size in bytes) counted against Config.MaxCost.
+// A value whose cost alone exceeds the shard's share of MaxCost is not stored.
+func (c *Cache[K, V]) SetWithCost(key K, value V, cost int64, expire time.Duration) {
+	c.shard(key).set(key, value, cost, expire, false, nil)
+}
+
+// SetWithTags stores the value under the given tags, so it can be dropped together with DeleteByTag.
+func (c *Cache[K, V]) SetWithTags(key K, value V, expire time.Duration, tags ...string) {
+	c.shard(key).set(key, value, 0, expire, false, tags)
+}
+
+func (c *Cache[K, V]) Expire(key K, expire time.Duration) {
+	c.shard(key).expire(key, expire)
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.shard(key).delete(key)
+}
+
+func (c *Cache[K, V]) cleanup() {
+	nowTime := time.Now().UnixNano()
+
+	for _, s := range c.shards {
+		s.cleanup(nowTime)
 	}
 }
 
-func (c *Cache) Delete(key string) {
-	c.Lock()
-	defer c.Unlock()
+func (c *Cache[K, V]) GetAll() map[K]V {
+	nowTime := time.Now().UnixNano()
+	items := make(map[K]V, initSize)
 
-	if _, ok := c.items[key]; ok {
-		delete(c.items, key)
+	for _, s := range c.shards {
+		s.copyTo(items, nowTime)
 	}
+
+	return items
+}
+
+func (c *Cache[K, V]) Config() *Config {
+	return c.conf
 }
 
-func (c *Cache) cleanup() {
-	c.Lock()
-	defer c.Unlock()
+func (c *Cache[K, V]) run() {
+	defer c.wg.Done()
 
-	nowTime := time.Now().Unix()
+	var snapshotC <-chan time.Time
 
-	for key, item := range c.items {
-		if item.expire > 0 && nowTime > item.createAt+item.expire {
-			delete(c.items, key)
+	if c.snapshotTimer != nil {
+		snapshotC = c.snapshotTimer.C
+	}
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-c.timer.C:
+			c.cleanup()
+		case <-snapshotC:
+			_ = c.snapshotToFile()
 		}
 	}
 }
 
-func (c *Cache) GetAll() map[string]interface{} {
-	c.RLock()
-	defer c.RUnlock()
+// Close stops the background goroutine and writes the final snapshot when Config.SnapshotFile is set.
+// The cache stays readable and writable afterwards.
+func (c *Cache[K, V]) Close() (err error) {
+	c.cancel()
+	c.timer.Stop()
 
-	nowTime := time.Now().Unix()
-	items := make(map[string]interface{}, len(c.items))
-
-	for key, item := range c.items {
-		if item.expire == 0 || nowTime < item.createAt+item.expire {
-			items[key] = item.data
-		}
+	if c.snapshotTimer != nil {
+		c.snapshotTimer.Stop()
 	}
 
-	return items
-}
+	c.wg.Wait()
 
-func (c *Cache) run() {
-	for range c.timer.C {
-		c.cleanup()
+	if c.conf.SnapshotFile != "" {
+		err = c.snapshotToFile()
 	}
+
+	return
 }
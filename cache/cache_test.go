@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+const benchKeys = 1 << 14
+
+func benchCache(b *testing.B, shards int) (*Cache[string, int], []string) {
+	conf := DefaultConfig()
+	conf.Shards = shards
+	c := New[string, int](conf)
+	b.Cleanup(func() { _ = c.Close() })
+
+	keys := make([]string, benchKeys)
+
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+		c.Set(keys[i], i, time.Hour)
+	}
+
+	b.ResetTimer()
+	return c, keys
+}
+
+func benchShards(b *testing.B, run func(b *testing.B, c *Cache[string, int], keys []string)) {
+	for _, shards := range []int{1, shardCount} {
+		b.Run("shards-"+strconv.Itoa(shards), func(b *testing.B) {
+			c, keys := benchCache(b, shards)
+			run(b, c, keys)
+		})
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	benchShards(b, func(b *testing.B, c *Cache[string, int], keys []string) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				c.Get(keys[i&(benchKeys-1)])
+			}
+		})
+	})
+}
+
+func BenchmarkSet(b *testing.B) {
+	benchShards(b, func(b *testing.B, c *Cache[string, int], keys []string) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				c.Set(keys[i&(benchKeys-1)], i, time.Hour)
+			}
+		})
+	})
+}
+
+// BenchmarkMixed does one Set for every nine Gets.
+func BenchmarkMixed(b *testing.B) {
+	benchShards(b, func(b *testing.B, c *Cache[string, int], keys []string) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				if key := keys[i&(benchKeys-1)]; i%10 == 0 {
+					c.Set(key, i, time.Hour)
+				} else {
+					c.Get(key)
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by a loader when the key has no value; with Config.NegativeTtl
+// set the miss is cached, so later GetOrLoad calls return it without calling the loader.
+var ErrNotFound = errors.New("cache: not found")
+
+type Loader[K comparable, V any] func(key K) (V, error)
+
+type call[V any] struct {
+	wg    sync.WaitGroup
+	value V
+	err   error
+}
+
+func (c *Cache[K, V]) load(key K, ttl time.Duration, loader Loader[K, V], wait bool) (value V, err error) {
+	c.loadLock.Lock()
+
+	if cl, ok := c.calls[key]; ok {
+		c.loadLock.Unlock()
+
+		if !wait {
+			return
+		}
+
+		cl.wg.Wait()
+		return cl.value, cl.err
+	}
+
+	cl := &call[V]{}
+	cl.wg.Add(1)
+	c.calls[key] = cl
+	c.loadLock.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			cl.err = fmt.Errorf("cache loader panic: %v", r)
+			err = cl.err
+		}
+
+		cl.wg.Done()
+
+		c.loadLock.Lock()
+		delete(c.calls, key)
+		c.loadLock.Unlock()
+	}()
+
+	cl.value, cl.err = loader(key)
+
+	switch {
+	case cl.err == nil:
+		c.Set(key, cl.value, ttl)
+	case errors.Is(cl.err, ErrNotFound) && c.conf.NegativeTtl > 0:
+		var zero V
+		c.shard(key).set(key, zero, 0, c.conf.NegativeTtl*time.Millisecond, true, nil)
+	}
+
+	return cl.value, cl.err
+}
+
+// GetOrLoad returns the cached value, or calls loader once per key however many goroutines miss together.
+// With Config.StaleTtl set, an expired value is still returned for that long while it is refreshed in the background.
+func (c *Cache[K, V]) GetOrLoad(key K, ttl time.Duration, loader Loader[K, V]) (value V, err error) {
+	s := c.shard(key)
+	s.Lock()
+
+	if item, ok := s.items[key]; ok {
+		nowTime := time.Now().UnixNano()
+
+		if !item.expired(nowTime) {
+			s.policy.touch(item)
+			s.recorder.hit(!item.negative)
+			negative := item.negative
+			value = item.data
+			s.Unlock()
+
+			if negative {
+				err = ErrNotFound
+			}
+
+			return
+		}
+
+		if s.stale(item, nowTime) {
+			s.recorder.hit(true)
+			value = item.data
+			s.Unlock()
+
+			go func() { _, _ = c.load(key, ttl, loader, false) }()
+			return
+		}
+	}
+
+	s.Unlock()
+	s.recorder.hit(false)
+
+	return c.load(key, ttl, loader, true)
+}
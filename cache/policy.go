@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+const (
+	PolicyLRU = iota
+	PolicyLFU
+)
+
+type policy[K comparable, V any] interface {
+	push(item *Item[K, V])
+	touch(item *Item[K, V])
+	remove(item *Item[K, V])
+	victim() *Item[K, V]
+}
+
+func newPolicy[K comparable, V any](kind int) policy[K, V] {
+	switch kind {
+	case PolicyLFU:
+		return &lfuPolicy[K, V]{items: make(lfuHeap[K, V], 0, initSize)}
+	default:
+		return &lruPolicy[K, V]{list: list.New()}
+	}
+}
+
+type lruPolicy[K comparable, V any] struct {
+	list *list.List
+}
+
+func (p *lruPolicy[K, V]) push(item *Item[K, V]) {
+	item.element = p.list.PushFront(item)
+}
+
+func (p *lruPolicy[K, V]) touch(item *Item[K, V]) {
+	p.list.MoveToFront(item.element)
+}
+
+func (p *lruPolicy[K, V]) remove(item *Item[K, V]) {
+	p.list.Remove(item.element)
+	item.element = nil
+}
+
+func (p *lruPolicy[K, V]) victim() *Item[K, V] {
+	if e := p.list.Back(); e != nil {
+		return e.Value.(*Item[K, V])
+	}
+
+	return nil
+}
+
+type lfuHeap[K comparable, V any] []*Item[K, V]
+
+func (h lfuHeap[K, V]) Len() int {
+	return len(h)
+}
+
+func (h lfuHeap[K, V]) Less(i, j int) bool {
+	if h[i].freq == h[j].freq {
+		return h[i].tick < h[j].tick
+	}
+
+	return h[i].freq < h[j].freq
+}
+
+func (h lfuHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap[K, V]) Push(x interface{}) {
+	item := x.(*Item[K, V])
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *lfuHeap[K, V]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+	return item
+}
+
+type lfuPolicy[K comparable, V any] struct {
+	items lfuHeap[K, V]
+	tick  uint64
+}
+
+func (p *lfuPolicy[K, V]) push(item *Item[K, V]) {
+	p.tick++
+	item.freq, item.tick = 1, p.tick
+	heap.Push(&p.items, item)
+}
+
+func (p *lfuPolicy[K, V]) touch(item *Item[K, V]) {
+	p.tick++
+	item.freq, item.tick = item.freq+1, p.tick
+	heap.Fix(&p.items, item.index)
+}
+
+func (p *lfuPolicy[K, V]) remove(item *Item[K, V]) {
+	if item.index >= 0 {
+		heap.Remove(&p.items, item.index)
+	}
+}
+
+func (p *lfuPolicy[K, V]) victim() *Item[K, V] {
+	if len(p.items) > 0 {
+		return p.items[0]
+	}
+
+	return nil
+}
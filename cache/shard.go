@@ -0,0 +1,278 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type shard[K comparable, V any] struct {
+	sync.Mutex
+	items      map[K]*Item[K, V]
+	policy     policy[K, V]
+	cost       int64
+	maxEntries int
+	maxCost    int64
+	staleTtl   int64
+	recorder   *recorder[K, V]
+	pending    []removal[K, V]
+	tags       map[string]map[K]struct{}
+}
+
+func newShard[K comparable, V any](conf *Config, maxEntries int, maxCost int64, recorder *recorder[K, V]) *shard[K, V] {
+	size := initSize
+
+	if maxEntries > 0 && maxEntries < size {
+		size = maxEntries
+	}
+
+	return &shard[K, V]{
+		items:      make(map[K]*Item[K, V], size),
+		policy:     newPolicy[K, V](conf.Policy),
+		maxEntries: maxEntries,
+		maxCost:    maxCost,
+		staleTtl:   int64(conf.StaleTtl * time.Millisecond),
+		recorder:   recorder,
+		tags:       make(map[string]map[K]struct{}),
+	}
+}
+
+// unlock releases the shard and then runs evict handlers for entries removed while it was held.
+func (s *shard[K, V]) unlock() {
+	pending := s.pending
+	s.pending = nil
+	s.Unlock()
+
+	s.recorder.notify(pending)
+}
+
+func (s *shard[K, V]) get(key K, nowTime int64) (value V, ok bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	item, ok := s.items[key]
+
+	if !ok || item.negative || item.expired(nowTime) {
+		s.recorder.hit(false)
+		return value, false
+	}
+
+	s.policy.touch(item)
+	s.recorder.hit(true)
+	return item.data, true
+}
+
+func (s *shard[K, V]) set(key K, value V, cost int64, expire time.Duration, negative bool, tags []string) {
+	s.Lock()
+	defer s.unlock()
+
+	item, ok := s.items[key]
+
+	if s.maxCost > 0 && cost > s.maxCost {
+		if ok {
+			s.remove(item, EvictDeleted)
+		}
+
+		return
+	}
+
+	nowTime := time.Now().UnixNano()
+
+	if ok {
+		s.cost += cost - item.cost
+		item.data, item.cost, item.createAt = value, cost, nowTime
+		s.policy.touch(item)
+	} else {
+		item = &Item[K, V]{key: key, data: value, cost: cost, createAt: nowTime}
+		s.items[key] = item
+		s.cost += cost
+		s.policy.push(item)
+	}
+
+	item.expire, item.negative = 0, negative
+
+	if expire > 0 {
+		item.expire = int64(expire)
+	}
+
+	s.untag(item)
+	s.tag(item, tags)
+	s.evict()
+}
+
+func (s *shard[K, V]) expire(key K, expire time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	if item, ok := s.items[key]; ok {
+		if expire > 0 {
+			item.expire = int64(expire)
+		} else {
+			item.expire = 0
+		}
+	}
+}
+
+func (s *shard[K, V]) delete(key K) {
+	s.Lock()
+	defer s.unlock()
+
+	if item, ok := s.items[key]; ok {
+		s.remove(item, EvictDeleted)
+	}
+}
+
+func (s *shard[K, V]) tag(item *Item[K, V], tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	item.tags = tags
+
+	for _, tag := range tags {
+		keys, ok := s.tags[tag]
+
+		if !ok {
+			keys = make(map[K]struct{}, 16)
+			s.tags[tag] = keys
+		}
+
+		keys[item.key] = struct{}{}
+	}
+}
+
+func (s *shard[K, V]) untag(item *Item[K, V]) {
+	for _, tag := range item.tags {
+		if keys, ok := s.tags[tag]; ok {
+			delete(keys, item.key)
+
+			if len(keys) == 0 {
+				delete(s.tags, tag)
+			}
+		}
+	}
+
+	item.tags = nil
+}
+
+func (s *shard[K, V]) remove(item *Item[K, V], reason EvictReason) {
+	s.policy.remove(item)
+	s.cost -= item.cost
+	delete(s.items, item.key)
+	s.untag(item)
+
+	if item.negative {
+		return
+	}
+
+	s.recorder.removed(reason)
+
+	if s.recorder.watching() {
+		s.pending = append(s.pending, removal[K, V]{item.key, item.data, reason})
+	}
+}
+
+func (s *shard[K, V]) overflow() bool {
+	if s.maxEntries > 0 && len(s.items) > s.maxEntries {
+		return true
+	}
+
+	return s.maxCost > 0 && s.cost > s.maxCost
+}
+
+func (s *shard[K, V]) evict() {
+	for s.overflow() {
+		item := s.policy.victim()
+
+		if item == nil {
+			return
+		}
+
+		s.remove(item, EvictCapacity)
+	}
+}
+
+func (s *shard[K, V]) stale(item *Item[K, V], nowTime int64) bool {
+	if item.negative || item.expire == 0 || s.staleTtl <= 0 {
+		return false
+	}
+
+	return nowTime < item.createAt+item.expire+s.staleTtl
+}
+
+func (s *shard[K, V]) cleanup(nowTime int64) {
+	s.Lock()
+	defer s.unlock()
+
+	for _, item := range s.items {
+		if item.expired(nowTime) && !s.stale(item, nowTime) {
+			s.remove(item, EvictExpired)
+		}
+	}
+}
+
+func (s *shard[K, V]) copyTo(items map[K]V, nowTime int64) {
+	s.Lock()
+	defer s.Unlock()
+
+	for key, item := range s.items {
+		if !item.negative && !item.expired(nowTime) {
+			items[key] = item.data
+		}
+	}
+}
+
+func (s *shard[K, V]) deleteFunc(match func(key K) bool) (count int) {
+	s.Lock()
+	defer s.unlock()
+
+	for key, item := range s.items {
+		if match(key) {
+			s.remove(item, EvictDeleted)
+			count++
+		}
+	}
+
+	return
+}
+
+func (s *shard[K, V]) deleteTag(tag string) (count int) {
+	s.Lock()
+	defer s.unlock()
+
+	for key := range s.tags[tag] {
+		if item, ok := s.items[key]; ok {
+			s.remove(item, EvictDeleted)
+			count++
+		}
+	}
+
+	return
+}
+
+func (s *shard[K, V]) live(nowTime int64) (keys []K, values []V) {
+	s.Lock()
+	defer s.Unlock()
+
+	keys, values = make([]K, 0, len(s.items)), make([]V, 0, len(s.items))
+
+	for key, item := range s.items {
+		if !item.negative && !item.expired(nowTime) {
+			keys, values = append(keys, key), append(values, item.data)
+		}
+	}
+
+	return
+}
+
+func (s *shard[K, V]) count(nowTime int64) (count int) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, item := range s.items {
+		if !item.negative && !item.expired(nowTime) {
+			count++
+		}
+	}
+
+	return
+}
@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type snapshotItem[K comparable, V any] struct {
+	Key    K        `json:"key"`
+	Value  V        `json:"value"`
+	Cost   int64    `json:"cost"`
+	Expire int64    `json:"expire"` // unix nanoseconds, 0 never expires
+	Tags   []string `json:"tags,omitempty"`
+}
+
+func (s *shard[K, V]) snapshot(items []*snapshotItem[K, V], nowTime int64) []*snapshotItem[K, V] {
+	s.Lock()
+	defer s.Unlock()
+
+	for key, item := range s.items {
+		if item.negative || item.expired(nowTime) {
+			continue
+		}
+
+		si := &snapshotItem[K, V]{Key: key, Value: item.data, Cost: item.cost, Tags: item.tags}
+
+		if item.expire > 0 {
+			si.Expire = item.createAt + item.expire
+		}
+
+		items = append(items, si)
+	}
+
+	return items
+}
+
+// Save writes all live entries with their expiry time, encoded with Config.Coder or gob when it is nil.
+// With gob, concrete types stored behind interface values must be registered through gob.Register.
+func (c *Cache[K, V]) Save(w io.Writer) (err error) {
+	nowTime := time.Now().UnixNano()
+	items := make([]*snapshotItem[K, V], 0, initSize)
+
+	for _, s := range c.shards {
+		items = s.snapshot(items, nowTime)
+	}
+
+	if c.conf.Coder == nil {
+		return gob.NewEncoder(w).Encode(items)
+	}
+
+	data, err := c.conf.Coder.Marshal(items)
+
+	if err != nil {
+		return
+	}
+
+	_, err = w.Write(data)
+	return
+}
+
+// Load restores entries written by Save; an entry keeps its expiry time, so the time the snapshot
+// spent on disk counts against its ttl and entries that expired meanwhile are dropped.
+func (c *Cache[K, V]) Load(r io.Reader) (err error) {
+	var items []*snapshotItem[K, V]
+
+	if c.conf.Coder == nil {
+		err = gob.NewDecoder(r).Decode(&items)
+	} else {
+		var data []byte
+
+		if data, err = ioutil.ReadAll(r); err == nil {
+			err = c.conf.Coder.Unmarshal(data, &items)
+		}
+	}
+
+	if err != nil {
+		return
+	}
+
+	nowTime := time.Now().UnixNano()
+
+	for _, item := range items {
+		var ttl time.Duration
+
+		if item.Expire > 0 {
+			if ttl = time.Duration(item.Expire - nowTime); ttl <= 0 {
+				continue
+			}
+		}
+
+		c.shard(item.Key).set(item.Key, item.Value, item.Cost, ttl, false, item.Tags)
+	}
+
+	return
+}
+
+// SaveFile writes the snapshot to a temporary file and renames it, so a crash never leaves a truncated snapshot.
+func (c *Cache[K, V]) SaveFile(file string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return
+	}
+
+	tmpFile := file + ".tmp"
+	fd, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return
+	}
+
+	writer := bufio.NewWriter(fd)
+
+	if err = c.Save(writer); err == nil {
+		err = writer.Flush()
+	}
+
+	if e := fd.Close(); err == nil {
+		err = e
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		return
+	}
+
+	return os.Rename(tmpFile, file)
+}
+
+func (c *Cache[K, V]) LoadFile(file string) (err error) {
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return
+	}
+
+	defer func() { _ = fd.Close() }()
+
+	return c.Load(bufio.NewReader(fd))
+}
+
+func (c *Cache[K, V]) restore() {
+	if err := c.LoadFile(c.conf.SnapshotFile); err != nil && !os.IsNotExist(err) {
+		c.setSnapshotErr(err)
+	}
+}
+
+func (c *Cache[K, V]) snapshotToFile() error {
+	err := c.SaveFile(c.conf.SnapshotFile)
+	c.setSnapshotErr(err)
+	return err
+}
+
+func (c *Cache[K, V]) setSnapshotErr(err error) {
+	c.snapshotLock.Lock()
+	c.snapshotErr = err
+	c.snapshotLock.Unlock()
+}
+
+// SnapshotError returns the error of the latest automatic restore or snapshot of Config.SnapshotFile.
+func (c *Cache[K, V]) SnapshotError() error {
+	c.snapshotLock.Lock()
+	defer c.snapshotLock.Unlock()
+
+	return c.snapshotErr
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type EvictReason int
+
+const (
+	EvictCapacity EvictReason = iota + 1
+	EvictExpired
+	EvictDeleted
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictExpired:
+		return "expired"
+	case EvictDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+type EvictHandler[K comparable, V any] func(key K, value V, reason EvictReason)
+
+type Stats struct {
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Evictions   int64 `json:"evictions"`
+	Expirations int64 `json:"expirations"`
+	Size        int64 `json:"size"`
+	Cost        int64 `json:"cost"`
+}
+
+func (s Stats) HitRate() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+
+	return 0
+}
+
+type removal[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictReason
+}
+
+type recorder[K comparable, V any] struct {
+	hits        int64
+	misses      int64
+	evictions   int64
+	expirations int64
+	handled     int32
+	locker      sync.RWMutex
+	handlers    []EvictHandler[K, V]
+}
+
+func (r *recorder[K, V]) hit(ok bool) {
+	if ok {
+		atomic.AddInt64(&r.hits, 1)
+	} else {
+		atomic.AddInt64(&r.misses, 1)
+	}
+}
+
+func (r *recorder[K, V]) removed(reason EvictReason) {
+	switch reason {
+	case EvictCapacity:
+		atomic.AddInt64(&r.evictions, 1)
+	case EvictExpired:
+		atomic.AddInt64(&r.expirations, 1)
+	}
+}
+
+func (r *recorder[K, V]) watching() bool {
+	return atomic.LoadInt32(&r.handled) == 1
+}
+
+func (r *recorder[K, V]) addHandler(handler EvictHandler[K, V]) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+
+	r.handlers = append(r.handlers, handler)
+	atomic.StoreInt32(&r.handled, 1)
+}
+
+func (r *recorder[K, V]) notify(removals []removal[K, V]) {
+	if len(removals) == 0 {
+		return
+	}
+
+	r.locker.RLock()
+	handlers := r.handlers
+	r.locker.RUnlock()
+
+	for _, rm := range removals {
+		for _, handler := range handlers {
+			handler(rm.key, rm.value, rm.reason)
+		}
+	}
+}
+
+// OnEvict registers a handler called for every entry removed by capacity eviction, expiry or Delete.
+// Handlers run after the shard lock is released, so they may use the cache.
+func (c *Cache[K, V]) OnEvict(handler EvictHandler[K, V]) {
+	c.recorder.addHandler(handler)
+}
+
+func (c *Cache[K, V]) Stats() Stats {
+	stats := Stats{
+		Hits:        atomic.LoadInt64(&c.recorder.hits),
+		Misses:      atomic.LoadInt64(&c.recorder.misses),
+		Evictions:   atomic.LoadInt64(&c.recorder.evictions),
+		Expirations: atomic.LoadInt64(&c.recorder.expirations),
+	}
+
+	for _, s := range c.shards {
+		s.Lock()
+		stats.Size += int64(len(s.items))
+		stats.Cost += s.cost
+		s.Unlock()
+	}
+
+	return stats
+}
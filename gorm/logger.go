@@ -21,19 +21,19 @@ func (l *logger) LogMode(_ gLogger.LogLevel) gLogger.Interface {
 	return l
 }
 
-func (l *logger) Info(_ context.Context, format string, args ...interface{}) {
-	l.recorder.Infof(format, args)
+func (l *logger) Info(ctx context.Context, format string, args ...interface{}) {
+	l.recorder.WithContext(ctx).Infof(format, args...)
 }
 
-func (l *logger) Warn(_ context.Context, format string, args ...interface{}) {
-	l.recorder.Warningf(format, args)
+func (l *logger) Warn(ctx context.Context, format string, args ...interface{}) {
+	l.recorder.WithContext(ctx).Warningf(format, args...)
 }
 
-func (l *logger) Error(_ context.Context, format string, args ...interface{}) {
-	l.recorder.Errorf(format, args)
+func (l *logger) Error(ctx context.Context, format string, args ...interface{}) {
+	l.recorder.WithContext(ctx).Errorf(format, args...)
 }
 
-func (l *logger) Trace(_ context.Context, begin time.Time, fc func() (string, int64), err error) {
+func (l *logger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
 	logLevel := l.recorder.Level()
 
 	if logLevel == oLogger.DisableLevel {
@@ -44,13 +44,14 @@ func (l *logger) Trace(_ context.Context, begin time.Time, fc func() (string, in
 	hasErr := err != nil && err != gorm.ErrRecordNotFound
 
 	var printer func(string, ...interface{})
+	recorder := l.recorder.WithContext(ctx)
 
 	if hasErr && logLevel >= oLogger.ErrorLevel {
-		printer = l.recorder.Errorf
+		printer = recorder.Errorf
 	} else if l.deadline > 0 && useTime > l.deadline && logLevel >= oLogger.WarnLevel {
-		printer = l.recorder.Warningf
+		printer = recorder.Warningf
 	} else if logLevel >= oLogger.DebugLevel {
-		printer = l.recorder.Debugf
+		printer = recorder.Debugf
 	} else {
 		return
 	}
@@ -7,11 +7,14 @@ import (
 
 	"github.com/marsmay/golib/logger"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/grpclog"
 )
 
 type Config struct {
-	Host string `toml:"host" json:"host"`
-	Port int    `toml:"port" json:"port"`
+	Host       string `toml:"host" json:"host"`
+	Port       int    `toml:"port" json:"port"`
+	LibraryLog bool   `toml:"library_log" json:"library_log"`
+	Verbosity  int    `toml:"verbosity" json:"verbosity"`
 }
 
 func (c *Config) GetAddr() string {
@@ -52,6 +55,8 @@ func (s *Server) Start(options ...grpc.ServerOption) error {
 	s.ctx, s.canceler = context.WithCancel(context.Background())
 
 	go func() {
+		defer logger.RecoverAndLog(s.logger, true)
+
 		err = s.server.Serve(listener)
 
 		if err != nil && s.Running() {
@@ -67,6 +72,12 @@ func (s *Server) Stop() {
 	s.server.Stop()
 }
 
+// NewServer installs l as the grpc library logger when c.LibraryLog is set; grpclog isn't safe to
+// change once grpc is in use, so it should be the first grpc call of the process.
 func NewServer(c *Config, r Router, l *logger.Logger) *Server {
+	if c.LibraryLog {
+		grpclog.SetLoggerV2(l.GrpcLogger(c.Verbosity))
+	}
+
 	return &Server{config: c, router: r, logger: l}
 }
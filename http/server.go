@@ -2,11 +2,13 @@ package http
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"net"
 	"net/http"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,12 +24,17 @@ type TlsConfig struct {
 	KeyPath  string `toml:"key_path" json:"key_path"`
 }
 
+// Config mounts the logger's LevelHandler at LogLevelPath. Anyone reaching the server can read the
+// levels, changing them takes LogLevelToken as a bearer token and is refused while it is empty;
+// mount the path on an internal listener only anyway.
 type Config struct {
-	Host  string     `toml:"host" json:"host"`
-	Port  int        `toml:"port" json:"port"`
-	Gzip  bool       `toml:"gzip" json:"gzip"`
-	PProf bool       `toml:"pprof" json:"pprof"`
-	Tls   *TlsConfig `toml:"tls" json:"tls"`
+	Host          string     `toml:"host" json:"host"`
+	Port          int        `toml:"port" json:"port"`
+	Gzip          bool       `toml:"gzip" json:"gzip"`
+	PProf         bool       `toml:"pprof" json:"pprof"`
+	LogLevelPath  string     `toml:"log_level_path" json:"log_level_path"`
+	LogLevelToken string     `toml:"log_level_token" json:"log_level_token"`
+	Tls           *TlsConfig `toml:"tls" json:"tls"`
 }
 
 func (c *Config) GetAddr() string {
@@ -85,7 +92,7 @@ func (s *Server) Recovery(ctx *gin.Context) {
 			}
 
 			request := fmt.Sprintf("%s | %s %s | %s | %s", ctx.ClientIP(), ctx.Request.Method, ctx.Request.URL.RequestURI(), ctx.Request.UserAgent(), s.router.GetIdentifier(ctx))
-			s.logger.Error(fmt.Sprintf("recovered panic:\nRequest: %s\nTrace: %s\n%s", request, err, stacktrace))
+			s.logger.WithContext(ctx).Error(fmt.Sprintf("recovered panic:\nRequest: %s\nTrace: %s\n%s", request, err, stacktrace))
 
 			ctx.Status(http.StatusInternalServerError)
 			ctx.Abort()
@@ -102,7 +109,19 @@ func (s *Server) AccessLog(ctx *gin.Context) {
 	idf := s.router.GetIdentifier(ctx)
 	statusCode, useTime, clientIp := ctx.Writer.Status(), time.Since(start), ctx.ClientIP()
 	uri, method, userAgent := ctx.Request.URL.RequestURI(), ctx.Request.Method, ctx.Request.UserAgent()
-	s.logger.Infof("request: %d | %4v | %s | %s %s | %s | %s", statusCode, useTime, clientIp, method, uri, userAgent, idf)
+	s.logger.WithContext(ctx).Infof("request: %d | %4v | %s | %s %s | %s | %s", statusCode, useTime, clientIp, method, uri, userAgent, idf)
+}
+
+// LevelAuth lets a request through only with the LogLevelToken bearer token.
+func (s *Server) LevelAuth(ctx *gin.Context) {
+	token := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+
+	if s.config.LogLevelToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.LogLevelToken)) != 1 {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": 1, "message": "invalid token"})
+		return
+	}
+
+	ctx.Next()
 }
 
 func CrossDomain(ctx *gin.Context) {
@@ -125,6 +144,8 @@ func UnGzip(ctx *gin.Context) {
 
 func (s *Server) Start() {
 	go func() {
+		defer logger.RecoverAndLog(s.logger, true)
+
 		var err error
 
 		if s.config.Tls.Enable {
@@ -174,6 +195,17 @@ func NewServer(c *Config, r Router, l *logger.Logger) *Server {
 		pprof.Register(router)
 	}
 
+	// enable log level api
+	if c.LogLevelPath != "" {
+		if c.LogLevelToken == "" {
+			l.Warningf("log level api at %s is read only, set log_level_token to change levels", c.LogLevelPath)
+		}
+
+		router.GET(c.LogLevelPath, l.LevelHandler)
+		router.PUT(c.LogLevelPath, server.LevelAuth, l.LevelHandler)
+		router.POST(c.LogLevelPath, server.LevelAuth, l.LevelHandler)
+	}
+
 	// set route
 	server.router.RegHttpHandler(router)
 
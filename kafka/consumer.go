@@ -16,6 +16,7 @@ type ConsumerConfig struct {
 	Group        string   `toml:"group" json:"group"`
 	Worker       int      `toml:"worker" json:"worker"`
 	OffsetNewest bool     `toml:"offset_newest" json:"offset_newest"`
+	LibraryLog   bool     `toml:"library_log" json:"library_log"`
 }
 
 type Consumer struct {
@@ -51,6 +52,7 @@ func (c *Consumer) Stop() {
 
 func (c *Consumer) logErr() {
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for {
 		select {
@@ -64,26 +66,32 @@ func (c *Consumer) logErr() {
 
 func (c *Consumer) logNotice() {
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for {
 		select {
 		case <-c.ctx.Done():
 			return
 		case msg := <-c.client.Notifications():
-			c.logger.Debugf("kafka consumer revice notification | brokers: %+v | group: %s | message: %s", c.c.Brokers, c.c.Group, msg)
+			c.logger.Debugf("kafka consumer revice notification | brokers: %+v | group: %s | message: %+v", c.c.Brokers, c.c.Group, msg)
 		}
 	}
 }
 
 func (c *Consumer) receive() {
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for {
 		select {
 		case <-c.ctx.Done():
 			return
 		case msg := <-c.client.Messages():
-			err := c.handler(msg.Value)
+			var err error
+
+			if logger.Protect(c.logger, func() { err = c.handler(msg.Value) }) {
+				continue
+			}
 
 			if err != nil {
 				c.logger.Errorf("kafka consumer handler error | brokers: %+v | group: %s | error: %s", c.c.Brokers, c.c.Group, err)
@@ -96,6 +104,10 @@ func (c *Consumer) receive() {
 }
 
 func NewConsumer(c *ConsumerConfig, handler func([]byte) error, logger *logger.Logger) (consumer *Consumer, err error) {
+	if c.LibraryLog {
+		installLogger(logger)
+	}
+
 	config := cluster.NewConfig()
 	config.Consumer.Return.Errors = true
 
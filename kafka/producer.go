@@ -11,7 +11,13 @@ import (
 )
 
 type ProducerConfig struct {
-	Brokers []string `toml:"brokers" json:"brokers"`
+	Brokers    []string `toml:"brokers" json:"brokers"`
+	LibraryLog bool     `toml:"library_log" json:"library_log"`
+}
+
+// installLogger routes sarama's global connection log to l at debug level.
+func installLogger(l *logger.Logger) {
+	sarama.Logger = l.PrintLogger(logger.DebugLevel)
 }
 
 type Producer struct {
@@ -49,6 +55,7 @@ func (p *Producer) Send(topic string, payload []byte) error {
 
 func (p *Producer) logErr() {
 	defer p.wg.Done()
+	defer logger.RecoverAndLog(p.logger, true)
 
 	for {
 		select {
@@ -61,6 +68,10 @@ func (p *Producer) logErr() {
 }
 
 func NewProducer(c *ProducerConfig, logger *logger.Logger) (producer *Producer, err error) {
+	if c.LibraryLog {
+		installLogger(logger)
+	}
+
 	config := sarama.NewConfig()
 	config.Net.KeepAlive = 60 * time.Second
 	config.Producer.Return.Successes = false
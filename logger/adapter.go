@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// callerSkips are logging wrappers between a library and its adapter, the caller reported is the
+// first frame outside them.
+var callerSkips = []string{
+	"/src/log/log.go",
+	"/src/runtime/",
+	"/src/internal/runtime/",
+	"/grpclog/",
+}
+
+func skipCaller(file string) bool {
+	for _, skip := range callerSkips {
+		if strings.Contains(file, skip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sprintln(args []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
+
+// PrintLogger writes Print-style calls at a fixed level. It satisfies sarama.StdLogger and
+// the paho mqtt Logger, e.g. mqtt.ERROR = l.PrintLogger(logger.ErrorLevel).
+type PrintLogger struct {
+	logger *Logger
+	level  Level
+}
+
+func (l *Logger) PrintLogger(level Level) *PrintLogger {
+	return &PrintLogger{logger: l, level: level}
+}
+
+func (p *PrintLogger) Print(args ...interface{}) {
+	p.logger.print(p.level, args)
+}
+
+func (p *PrintLogger) Printf(format string, args ...interface{}) {
+	p.logger.Log(p.level, format, args...)
+}
+
+func (p *PrintLogger) Println(args ...interface{}) {
+	p.logger.print(p.level, []interface{}{sprintln(args)})
+}
+
+// Write lets a PrintLogger back a standard library *log.Logger, one call per line.
+func (p *PrintLogger) Write(b []byte) (n int, err error) {
+	p.logger.print(p.level, []interface{}{strings.TrimSuffix(string(b), "\n")})
+	return len(b), nil
+}
+
+// StdLogger returns a standard library logger that writes at level, for libraries that take a *log.Logger.
+func (l *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(l.PrintLogger(level), "", 0)
+}
+
+// RedirectStdLog sends the output of the standard library's global logger to l at level,
+// the returned function restores the previous output and flags.
+func (l *Logger) RedirectStdLog(level Level) func() {
+	w, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+
+	log.SetOutput(l.PrintLogger(level))
+	log.SetFlags(0)
+	log.SetPrefix("")
+
+	return func() {
+		log.SetOutput(w)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	}
+}
+
+// GrpcLogger satisfies grpclog.LoggerV2: INFO, WARNING, ERROR and FATAL map to the logger levels
+// of the same name, and V reports verbosity levels up to the given one.
+type GrpcLogger struct {
+	logger    *Logger
+	verbosity int
+}
+
+func (l *Logger) GrpcLogger(verbosity int) *GrpcLogger {
+	return &GrpcLogger{logger: l, verbosity: verbosity}
+}
+
+func (g *GrpcLogger) Info(args ...interface{}) {
+	g.logger.print(InfoLevel, args)
+}
+
+func (g *GrpcLogger) Infoln(args ...interface{}) {
+	g.logger.print(InfoLevel, []interface{}{sprintln(args)})
+}
+
+func (g *GrpcLogger) Infof(format string, args ...interface{}) {
+	g.logger.Log(InfoLevel, format, args...)
+}
+
+func (g *GrpcLogger) Warning(args ...interface{}) {
+	g.logger.print(WarnLevel, args)
+}
+
+func (g *GrpcLogger) Warningln(args ...interface{}) {
+	g.logger.print(WarnLevel, []interface{}{sprintln(args)})
+}
+
+func (g *GrpcLogger) Warningf(format string, args ...interface{}) {
+	g.logger.Log(WarnLevel, format, args...)
+}
+
+func (g *GrpcLogger) Error(args ...interface{}) {
+	g.logger.print(ErrorLevel, args)
+}
+
+func (g *GrpcLogger) Errorln(args ...interface{}) {
+	g.logger.print(ErrorLevel, []interface{}{sprintln(args)})
+}
+
+func (g *GrpcLogger) Errorf(format string, args ...interface{}) {
+	g.logger.Log(ErrorLevel, format, args...)
+}
+
+// Fatal, Fatalln and Fatalf flush every log file before returning, grpc exits the process right after.
+func (g *GrpcLogger) Fatal(args ...interface{}) {
+	g.logger.print(FatalLevel, args)
+	g.logger.closeAll()
+}
+
+func (g *GrpcLogger) Fatalln(args ...interface{}) {
+	g.logger.print(FatalLevel, []interface{}{sprintln(args)})
+	g.logger.closeAll()
+}
+
+func (g *GrpcLogger) Fatalf(format string, args ...interface{}) {
+	g.logger.Log(FatalLevel, format, args...)
+	g.logger.closeAll()
+}
+
+func (g *GrpcLogger) V(l int) bool {
+	return l <= g.verbosity
+}
@@ -3,97 +3,420 @@ package logger
 import (
 	"bufio"
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+const (
+	logSuffix        = ".log"
+	defaultQueueSize = 8192
+)
+
+const (
+	PolicyBlock      = "block"
+	PolicyDropNewest = "drop_newest"
+	PolicyDropOldest = "drop_oldest"
+	PolicyTimeout    = "timeout"
+)
+
+var ErrWriterClosed = errors.New("logger: writer is closed")
+
+// Queue controls what Write does when the writer goroutine falls behind: block (default), drop the
+// new line, drop the oldest queued line, or block for at most QueueTimeout milliseconds and then drop.
+type Queue struct {
+	QueueSize    int           `toml:"queue_size" json:"queue_size"`
+	QueuePolicy  string        `toml:"queue_policy" json:"queue_policy"`
+	QueueTimeout time.Duration `toml:"queue_timeout" json:"queue_timeout"`
+}
+
+type WriterStats struct {
+	Dropped  int64 `json:"dropped"`
+	Blocked  int64 `json:"blocked"`
+	Errors   int64 `json:"errors"`
+	Queued   int   `json:"queued"`
+	Capacity int   `json:"capacity"`
+}
+
+type writerOptions struct {
+	retention   *Retention
+	maxFileSize int64
+	queue       *Queue
+	header      func() []byte
+}
+
 type asyncWriter struct {
-	dir      string
-	file     string
-	fd       *os.File
-	writer   *bufio.Writer
-	msgQueue chan []byte
-	timer    *time.Ticker
-	getFile  func() string
-	ctx      context.Context
-	cancel   context.CancelFunc
-	end      chan bool
-}
-
-func newAsyncWriter(dir string, getFile func() string) (writer *asyncWriter, err error) {
+	dir        string
+	prefix     string
+	period     string
+	segment    int
+	file       string
+	size       int64
+	fd         *os.File
+	writer     *bufio.Writer
+	msgQueue   chan []byte
+	policy     string
+	timeout    time.Duration
+	locker     sync.RWMutex
+	closed     bool
+	dropped    int64
+	blocked    int64
+	errors     int64
+	firstErr   error
+	timer      *time.Ticker
+	getFile    func() string
+	retention  *Retention
+	maxSize    int64
+	header     func() []byte
+	currFile   atomic.Value
+	rotateSign chan bool
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         *sync.WaitGroup
+	end        chan error
+}
+
+func newAsyncWriter(dir, prefix string, getFile func() string, opts *writerOptions) (writer *asyncWriter, err error) {
 	writer = &asyncWriter{
-		dir:      dir,
-		getFile:  getFile,
-		msgQueue: make(chan []byte, 8192),
-		timer:    time.NewTicker(time.Second),
-		end:      make(chan bool, 1),
+		dir:        dir,
+		prefix:     prefix,
+		getFile:    getFile,
+		retention:  opts.retention,
+		maxSize:    opts.maxFileSize * 1024 * 1024,
+		header:     opts.header,
+		rotateSign: make(chan bool, 1),
+		wg:         &sync.WaitGroup{},
+		end:        make(chan error, 1),
 	}
-	writer.ctx, writer.cancel = context.WithCancel(context.Background())
+
+	queueSize := defaultQueueSize
+
+	if q := opts.queue; q != nil {
+		if q.QueueSize > 0 {
+			queueSize = q.QueueSize
+		}
+
+		writer.policy, writer.timeout = q.QueuePolicy, q.QueueTimeout*time.Millisecond
+	}
+
+	writer.msgQueue = make(chan []byte, queueSize)
 
 	if err = os.MkdirAll(writer.dir, 0755); err != nil {
 		return
 	}
 
-	writer.refresh()
-	writer.fd, err = os.OpenFile(writer.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	writer.setPeriod(writer.getFile())
 
-	if err != nil {
+	if err = writer.open(); err != nil {
 		return
 	}
 
 	writer.writer = bufio.NewWriter(writer.fd)
+	writer.timer = time.NewTicker(time.Second)
+	writer.ctx, writer.cancel = context.WithCancel(context.Background())
 
-	go writer.flush()
+	registerWriter(writer)
 	go writer.start()
 
+	if writer.retention.enabled() {
+		writer.wg.Add(1)
+		go writer.janitor()
+	}
+
+	return
+}
+
+func (l *asyncWriter) current() string {
+	file, _ := l.currFile.Load().(string)
+	return file
+}
+
+// segmentFile names the n-th size segment of a period file: 20261018.log, 20261018.1.log, ...
+func segmentFile(period string, n int) string {
+	if n == 0 {
+		return period
+	}
+
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(period, logSuffix), n, logSuffix)
+}
+
+// lastSegment finds the newest segment of a period already on disk, so a restart keeps appending to it.
+// A compressed segment can't be appended to, so the one after it is used instead.
+func lastSegment(period string) (n int) {
+	base := strings.TrimSuffix(period, logSuffix)
+
+	if _, err := os.Stat(period + compressSuffix); err == nil {
+		n = 1
+	}
+
+	matches, _ := filepath.Glob(base + ".*" + logSuffix + "*")
+
+	for _, match := range matches {
+		name := strings.TrimPrefix(match, base+".")
+		compressed := strings.HasSuffix(name, compressSuffix)
+		v, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(name, compressSuffix), logSuffix))
+
+		if err != nil {
+			continue
+		}
+
+		if compressed {
+			v++
+		}
+
+		if v > n {
+			n = v
+		}
+	}
+
+	return
+}
+
+func (l *asyncWriter) setFile() {
+	l.file = segmentFile(l.period, l.segment)
+	l.currFile.Store(l.file)
+}
+
+func (l *asyncWriter) setPeriod(period string) {
+	l.period, l.segment = period, 0
+
+	if l.maxSize > 0 {
+		l.segment = lastSegment(period)
+	}
+
+	l.setFile()
+}
+
+func (l *asyncWriter) open() (err error) {
+	l.fd, err = os.OpenFile(l.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		l.fd = nil
+		return
+	}
+
+	l.size = 0
+
+	if info, e := l.fd.Stat(); e == nil {
+		l.size = info.Size()
+	}
+
+	// the header goes on top before any line, a raw line can't reach a new file first; the buffer is
+	// empty here, so writing to the file directly keeps the order
+	if l.size == 0 && l.header != nil {
+		if header := l.header(); len(header) > 0 {
+			n, e := l.fd.Write(header)
+			l.size += int64(n)
+			l.check(e)
+		}
+	}
+
 	return
 }
 
-func (l *asyncWriter) refresh() bool {
-	oldFile := l.file
-	l.file = l.getFile()
-	return l.file != oldFile
+// rotate flushes and closes the current file before next publishes the new name, so the janitor
+// never compresses or removes a file that still has buffered lines.
+func (l *asyncWriter) rotate(next func()) {
+	l.check(l.writer.Flush())
+
+	if l.fd != nil {
+		l.check(l.fd.Close())
+		l.fd = nil
+	}
+
+	next()
+
+	if err := l.open(); err != nil {
+		// lines go to stderr until a tick manages to open the file
+		l.check(err)
+		l.size = 0
+		l.writer.Reset(os.Stderr)
+	} else {
+		l.writer.Reset(l.fd)
+	}
+
+	select {
+	case l.rotateSign <- true:
+	default:
+	}
+}
+
+func (l *asyncWriter) reportErr(err error) {
+	_, _ = fmt.Fprintf(os.Stderr, "logger: %s\n", err)
+}
+
+// check records a write, flush or open error of the writer goroutine, Close returns the first one.
+func (l *asyncWriter) check(err error) {
+	if err == nil {
+		return
+	}
+
+	if atomic.AddInt64(&l.errors, 1) == 1 {
+		l.firstErr = err
+		l.reportErr(err)
+	}
+}
+
+func (l *asyncWriter) write(msg []byte) {
+	// roll over before the message rather than inside it, so a line never spans two segments
+	if l.maxSize > 0 && l.fd != nil && l.size > 0 && l.size+int64(len(msg)) > l.maxSize {
+		l.rotate(func() {
+			l.segment++
+			l.setFile()
+		})
+	}
+
+	// the header may only be known once the first record is encoded, after the first file was opened
+	if l.size == 0 && l.header != nil {
+		if header := l.header(); len(header) > 0 {
+			n, err := l.writer.Write(header)
+			l.size += int64(n)
+			l.check(err)
+		}
+	}
+
+	n, err := l.writer.Write(msg)
+	l.size += int64(n)
+	l.check(err)
 }
 
 func (l *asyncWriter) start() {
-	for msg := range l.msgQueue {
-		if msg == nil {
-			_ = l.writer.Flush()
-
-			if l.refresh() {
-				_ = l.fd.Close()
-				l.fd, _ = os.OpenFile(l.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-				l.writer.Reset(l.fd)
+	defer l.timer.Stop()
+
+	for {
+		select {
+		case msg, ok := <-l.msgQueue:
+			if !ok {
+				l.end <- l.finish()
+				return
+			}
+
+			l.write(msg)
+		case <-l.timer.C:
+			l.check(l.writer.Flush())
+
+			if period := l.getFile(); period != l.period {
+				l.rotate(func() {
+					l.setPeriod(period)
+				})
+			} else if l.fd == nil {
+				l.rotate(func() {})
 			}
-		} else {
-			_, _ = l.writer.Write(msg)
 		}
 	}
-
-	l.end <- true
 }
 
-func (l *asyncWriter) flush() {
-	for range l.timer.C {
-		l.msgQueue <- nil
+func (l *asyncWriter) finish() (err error) {
+	l.check(l.writer.Flush())
+
+	if l.fd != nil {
+		l.check(l.fd.Sync())
+		l.check(l.fd.Close())
+		l.fd = nil
 	}
+
+	if n := atomic.LoadInt64(&l.errors); n > 0 {
+		err = fmt.Errorf("logger: %d write errors, first: %w", n, l.firstErr)
+	}
+
+	return
 }
 
-func (l *asyncWriter) Write(p []byte) (n int, err error) {
+func (l *asyncWriter) enqueue(p []byte) {
+	enqueue(l.msgQueue, p, l.policy, l.timeout, &l.dropped, &l.blocked)
+}
+
+// enqueue puts item on queue according to the Queue policy, counting dropped and blocked writes.
+func enqueue[T any](queue chan T, item T, policy string, timeout time.Duration, dropped, blocked *int64) {
 	select {
-	case <-l.ctx.Done():
+	case queue <- item:
+		return
+	default:
+	}
+
+	switch policy {
+	case PolicyDropNewest:
+		atomic.AddInt64(dropped, 1)
+	case PolicyDropOldest:
+		for {
+			select {
+			case <-queue:
+				atomic.AddInt64(dropped, 1)
+			default:
+			}
+
+			select {
+			case queue <- item:
+				return
+			default:
+			}
+		}
+	case PolicyTimeout:
+		atomic.AddInt64(blocked, 1)
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case queue <- item:
+		case <-timer.C:
+			atomic.AddInt64(dropped, 1)
+		}
 	default:
-		l.msgQueue <- p
+		atomic.AddInt64(blocked, 1)
+		queue <- item
+	}
+}
+
+func (l *asyncWriter) Write(p []byte) (n int, err error) {
+	l.locker.RLock()
+	defer l.locker.RUnlock()
+
+	if l.closed {
+		atomic.AddInt64(&l.dropped, 1)
+		return 0, ErrWriterClosed
 	}
 
+	// p belongs to the caller once Write returns, e.g. the buffer of fmt.Fprintln
+	b := make([]byte, len(p))
+	copy(b, p)
+
+	l.enqueue(b)
 	return len(p), nil
 }
 
+func (l *asyncWriter) Stats() WriterStats {
+	return WriterStats{
+		Dropped:  atomic.LoadInt64(&l.dropped),
+		Blocked:  atomic.LoadInt64(&l.blocked),
+		Errors:   atomic.LoadInt64(&l.errors),
+		Queued:   len(l.msgQueue),
+		Capacity: cap(l.msgQueue),
+	}
+}
+
+// Close waits until every queued line is written and the file is synced, then returns the
+// first write, flush or sync error seen during the writer's life.
 func (l *asyncWriter) Close() error {
-	l.cancel()
-	l.timer.Stop()
-	l.msgQueue <- nil
+	l.locker.Lock()
+
+	if l.closed {
+		l.locker.Unlock()
+		return ErrWriterClosed
+	}
+
+	l.closed = true
 	close(l.msgQueue)
-	<-l.end
-	return nil
+	l.locker.Unlock()
+
+	err := <-l.end
+	unregisterWriter(l)
+	l.cancel()
+	l.wg.Wait()
+	return err
 }
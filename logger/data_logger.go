@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,11 +14,15 @@ const (
 )
 
 type DataConfig struct {
-	Dir       string         `toml:"dir" json:"dir"`
-	Prefix    string         `toml:"prefix" json:"prefix"`
-	Partition int            `toml:"partition" json:"partition"`
-	Timezone  string         `toml:"timezone" json:"timezone"`
-	Location  *time.Location `toml:"-" json:"-"`
+	Dir         string         `toml:"dir" json:"dir"`
+	Prefix      string         `toml:"prefix" json:"prefix"`
+	Partition   int            `toml:"partition" json:"partition"`
+	Timezone    string         `toml:"timezone" json:"timezone"`
+	Location    *time.Location `toml:"-" json:"-"`
+	MaxFileSize int64          `toml:"max_file_size" json:"max_file_size"`
+	Format      string         `toml:"format" json:"format"`
+	Retention
+	Queue
 }
 
 func (c *DataConfig) LoadLoc() {
@@ -37,16 +42,27 @@ func DefaultDataConfig() *DataConfig {
 	}
 }
 
+// DataLogger writes one file per partition. Records passed to Write are JSON lines, or csv rows with a
+// header line on top of each file when Format is "csv"; the header comes from the first record, so only
+// Log lines written before any record can precede it. Set Retention.Compress to gzip a partition
+// file once the writer has moved on to the next one.
 type DataLogger struct {
 	conf   *DataConfig
 	writer io.WriteCloser
+	header atomic.Value
 }
 
 func NewDataLogger(conf *DataConfig) (l *DataLogger, err error) {
 	conf.LoadLoc()
 
 	l = &DataLogger{conf: conf}
-	l.writer, err = newAsyncWriter(conf.Dir, l.getFile)
+	opts := &writerOptions{retention: &conf.Retention, maxFileSize: conf.MaxFileSize, queue: &conf.Queue}
+
+	if conf.Format == FormatCsv {
+		opts.header = l.getHeader
+	}
+
+	l.writer, err = newAsyncWriter(conf.Dir, conf.Prefix, l.getFile, opts)
 	return
 }
 
@@ -75,6 +91,14 @@ func (l *DataLogger) Config() *DataConfig {
 	return l.conf
 }
 
-func (l *DataLogger) Close() {
-	_ = l.writer.Close()
+func (l *DataLogger) Stats() WriterStats {
+	if w, ok := l.writer.(*asyncWriter); ok {
+		return w.Stats()
+	}
+
+	return WriterStats{}
+}
+
+func (l *DataLogger) Close() error {
+	return l.writer.Close()
 }
@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const FormatCsv = "csv"
+
+var errRecordType = errors.New("logger: csv record must be a struct or a pointer to a struct")
+
+type recordColumn struct {
+	name  string
+	index []int
+}
+
+// recordColumns caches the csv columns of each record type.
+var recordColumns sync.Map
+
+// columnsOf lists the exported fields of a struct in declaration order, embedded structs inline.
+// A column is named by its csv tag, then its json tag, then the field name; "-" skips the field.
+func columnsOf(t reflect.Type) []*recordColumn {
+	if v, ok := recordColumns.Load(t); ok {
+		return v.([]*recordColumn)
+	}
+
+	columns := appendColumns(nil, t, nil)
+	recordColumns.Store(t, columns)
+	return columns
+}
+
+func appendColumns(columns []*recordColumn, t reflect.Type, index []int) []*recordColumn {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		idx := append(index[:len(index):len(index)], i)
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("csv") == "" {
+			columns = appendColumns(columns, f.Type, idx)
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := f.Tag.Get("csv")
+
+		if name == "" {
+			name = f.Tag.Get("json")
+		}
+
+		if name = strings.Split(name, ",")[0]; name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		columns = append(columns, &recordColumn{name: name, index: idx})
+	}
+
+	return columns
+}
+
+func csvValue(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+
+		v = v.Elem()
+	}
+
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case []byte:
+		return string(x)
+	case error:
+		return x.Error()
+	case fmt.Stringer:
+		return x.String()
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+func csvLine(fields []string) []byte {
+	b := new(bytes.Buffer)
+	w := csv.NewWriter(b)
+	_ = w.Write(fields)
+	w.Flush()
+	return b.Bytes()
+}
+
+func recordStruct(record interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(record)
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, errRecordType
+		}
+
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return v, errRecordType
+	}
+
+	return v, nil
+}
+
+// csvHeader is the header line of a csv DataLogger and the record type it was taken from.
+type csvHeader struct {
+	typ  reflect.Type
+	line []byte
+}
+
+// encodeCsv renders one row, the header of the first record type is kept for the writer to put on top
+// of every new partition file. All records of a csv DataLogger must share that type, others are rejected.
+func (l *DataLogger) encodeCsv(record interface{}) ([]byte, error) {
+	v, err := recordStruct(record)
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := columnsOf(v.Type())
+	header, ok := l.header.Load().(*csvHeader)
+
+	if !ok {
+		names := make([]string, len(columns))
+
+		for i, c := range columns {
+			names[i] = c.name
+		}
+
+		// the first writers may race, all of them check against the header that won
+		l.header.CompareAndSwap(nil, &csvHeader{typ: v.Type(), line: csvLine(names)})
+		header = l.header.Load().(*csvHeader)
+	}
+
+	if header.typ != v.Type() {
+		return nil, fmt.Errorf("logger: csv record type %s differs from the header type %s", v.Type(), header.typ)
+	}
+
+	fields := make([]string, len(columns))
+
+	for i, c := range columns {
+		if f, e := v.FieldByIndexErr(c.index); e == nil {
+			fields[i] = csvValue(f)
+		}
+	}
+
+	return csvLine(fields), nil
+}
+
+func (l *DataLogger) getHeader() []byte {
+	if header, ok := l.header.Load().(*csvHeader); ok {
+		return header.line
+	}
+
+	return nil
+}
+
+// Write appends one record to the current partition file, as a JSON line or as a csv row
+// depending on DataConfig.Format.
+func (l *DataLogger) Write(record interface{}) (err error) {
+	var b []byte
+
+	if l.conf.Format == FormatCsv {
+		b, err = l.encodeCsv(record)
+	} else if b, err = json.Marshal(record); err == nil {
+		b = append(b, '\n')
+	}
+
+	if err != nil {
+		return
+	}
+
+	_, err = l.writer.Write(b)
+	return
+}
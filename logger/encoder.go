@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	FormatText = "text"
+	FormatJson = "json"
+)
+
+const badKey = "!BADKEY"
+
+type entry struct {
+	level    Level
+	time     time.Time
+	file     string
+	line     int
+	template string
+	msg      string
+	fields   []interface{}
+}
+
+type encoder interface {
+	encode(w *bytes.Buffer, e *entry)
+}
+
+func newEncoder(conf *Config) encoder {
+	if conf.Format == FormatJson {
+		return &jsonEncoder{conf: conf}
+	}
+
+	return &textEncoder{conf: conf}
+}
+
+// eachField walks key-value pairs, a trailing value without a key is reported under badKey.
+func eachField(fields []interface{}, fn func(key string, value interface{})) {
+	for i := 0; i < len(fields); i += 2 {
+		if i+1 == len(fields) {
+			fn(badKey, fields[i])
+			return
+		}
+
+		key, ok := fields[i].(string)
+
+		if !ok {
+			key = fmt.Sprint(fields[i])
+		}
+
+		fn(key, fields[i+1])
+	}
+}
+
+type textEncoder struct {
+	conf *Config
+}
+
+func (t *textEncoder) encode(w *bytes.Buffer, e *entry) {
+	if t.conf.ShowIp && localIp != "" {
+		w.WriteByte('(')
+		w.WriteString(localIp)
+		w.WriteString(") ")
+	}
+
+	w.WriteString(GetLevelText(e.level, t.conf.Color))
+	w.WriteByte(' ')
+	w.WriteString(e.time.Format(t.conf.TimeFormat))
+	w.WriteByte(' ')
+
+	if s := fmt.Sprintf("<%s:%d> ", e.file, e.line); t.conf.Color {
+		w.WriteString(Blue(s))
+	} else {
+		w.WriteString(s)
+	}
+
+	w.WriteString(e.msg)
+
+	eachField(e.fields, func(key string, value interface{}) {
+		w.WriteString(" | ")
+		w.WriteString(key)
+		w.WriteString(": ")
+		_, _ = fmt.Fprintf(w, "%+v", value)
+	})
+
+	w.WriteByte('\n')
+}
+
+type jsonEncoder struct {
+	conf *Config
+}
+
+func writeJsonString(w *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	w.Write(b)
+}
+
+func writeJsonValue(w *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case error:
+		writeJsonString(w, v.Error())
+		return
+	case time.Duration:
+		writeJsonString(w, v.String())
+		return
+	case fmt.Stringer:
+		writeJsonString(w, v.String())
+		return
+	}
+
+	b, err := json.Marshal(value)
+
+	if err != nil {
+		writeJsonString(w, fmt.Sprintf("%+v", value))
+		return
+	}
+
+	w.Write(b)
+}
+
+func (j *jsonEncoder) encode(w *bytes.Buffer, e *entry) {
+	w.WriteString(`{"level":`)
+	writeJsonString(w, Levels[e.level].Name)
+	w.WriteString(`,"time":`)
+	writeJsonString(w, e.time.Format(j.conf.TimeFormat))
+	w.WriteString(`,"caller":`)
+	writeJsonString(w, e.file+":"+strconv.Itoa(e.line))
+
+	if j.conf.ShowIp && localIp != "" {
+		w.WriteString(`,"ip":`)
+		writeJsonString(w, localIp)
+	}
+
+	w.WriteString(`,"msg":`)
+	writeJsonString(w, e.msg)
+
+	eachField(e.fields, func(key string, value interface{}) {
+		w.WriteByte(',')
+		writeJsonString(w, key)
+		w.WriteByte(':')
+		writeJsonValue(w, value)
+	})
+
+	w.WriteString("}\n")
+}
@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+)
+
+const (
+	FatalLog  = "log"
+	FatalExit = "exit"
+)
+
+// openWriters holds every async file writer not yet closed, so a fatal exit can flush the
+// data loggers and other loggers of the process too.
+var openWriters = struct {
+	sync.Mutex
+	writers map[*asyncWriter]struct{}
+}{writers: make(map[*asyncWriter]struct{})}
+
+func registerWriter(w *asyncWriter) {
+	openWriters.Lock()
+	defer openWriters.Unlock()
+
+	openWriters.writers[w] = struct{}{}
+}
+
+func unregisterWriter(w *asyncWriter) {
+	openWriters.Lock()
+	defer openWriters.Unlock()
+
+	delete(openWriters.writers, w)
+}
+
+func closeWriters() {
+	openWriters.Lock()
+	writers := make([]*asyncWriter, 0, len(openWriters.writers))
+
+	for w := range openWriters.writers {
+		writers = append(writers, w)
+	}
+
+	openWriters.Unlock()
+
+	for _, w := range writers {
+		_ = w.Close()
+	}
+}
+
+// fatal runs after a Fatal line: with FatalAction "exit" the logger and every other open log file
+// are flushed and closed and the process exits with status 1, otherwise logging carries on.
+func (l *Logger) fatal() {
+	if l.conf.FatalAction != FatalExit {
+		return
+	}
+
+	l.closeAll()
+	os.Exit(1)
+}
+
+// closeAll flushes and closes the logger and every other open log file before the process exits.
+func (l *Logger) closeAll() {
+	_ = l.Close()
+	closeWriters()
+}
+
+// RecoverAndLog recovers a panic of the calling goroutine and logs it with the full stack at fatal level.
+// Without repanic the goroutine then ends quietly, so it suits one-shot goroutines; at the top of a
+// long-lived loop pass repanic, the logs are flushed and closed and the panic ends the process as before.
+// It must be deferred directly:
+//
+//	defer logger.RecoverAndLog(l, true)
+func RecoverAndLog(l *Logger, repanic bool) {
+	r := recover()
+
+	if r == nil {
+		return
+	}
+
+	logPanic(l, FatalLevel, r)
+
+	if repanic {
+		if l != nil {
+			l.closeAll()
+		}
+
+		panic(r)
+	}
+}
+
+// Protect calls fn and recovers a panic from it, logged at error level with the full stack. It reports
+// whether fn panicked, so a loop can give up on one message or callback and keep running, e.g.
+//
+//	for msg := range queue {
+//		logger.Protect(l, func() { handler(msg) })
+//	}
+func Protect(l *Logger, fn func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			logPanic(l, ErrorLevel, r)
+		}
+	}()
+
+	fn()
+	return
+}
+
+func logPanic(l *Logger, level Level, r interface{}) {
+	stack := debug.Stack()
+
+	if l == nil {
+		_, _ = fmt.Fprintf(os.Stderr, "panic: %v\n%s", r, stack)
+	} else {
+		l.Logw(level, "catch panic", "panic", r, "stack", string(stack))
+	}
+}
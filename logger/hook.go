@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const hookQueueSize = 1024
+
+// Alert is a log line handed to a Notifier. Template is the format the message was built from,
+// and Count is the number of lines the alert stands for when a notifier aggregated repeats.
+type Alert struct {
+	Level    Level
+	Time     time.Time
+	Caller   string
+	Template string
+	Message  string
+	Fields   []interface{}
+	Count    int
+}
+
+// Notifier receives the lines of a hook one at a time from the hook's own goroutine,
+// so a slow notifier never blocks logging. A notifier that is an io.Closer is closed with the logger.
+type Notifier interface {
+	Notify(alert *Alert) error
+}
+
+type hook struct {
+	level    Level
+	notifier Notifier
+	queue    chan *Alert
+	dropped  int64
+	end      chan bool
+}
+
+func newHook(level Level, notifier Notifier) *hook {
+	h := &hook{level: level, notifier: notifier, queue: make(chan *Alert, hookQueueSize), end: make(chan bool)}
+	go h.run()
+	return h
+}
+
+func reportHookErr(err error) {
+	_, _ = fmt.Fprintf(os.Stderr, "logger: hook notify failed: %s\n", err)
+}
+
+func (h *hook) fire(alert *Alert) {
+	select {
+	case h.queue <- alert:
+	default:
+		atomic.AddInt64(&h.dropped, 1)
+	}
+}
+
+func (h *hook) run() {
+	defer close(h.end)
+
+	for alert := range h.queue {
+		if err := h.notifier.Notify(alert); err != nil {
+			reportHookErr(err)
+		}
+	}
+}
+
+func (h *hook) close() (err error) {
+	close(h.queue)
+	<-h.end
+
+	if n := atomic.LoadInt64(&h.dropped); n > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "logger: hook dropped %d lines\n", n)
+	}
+
+	if c, ok := h.notifier.(io.Closer); ok {
+		err = c.Close()
+	}
+
+	return
+}
+
+// hookSet is shared by a logger and its With children like sinkSet.
+type hookSet struct {
+	locker sync.RWMutex
+	closed bool
+	hooks  []*hook
+}
+
+func (s *hookSet) add(h *hook) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	hooks := make([]*hook, 0, len(s.hooks)+1)
+	s.hooks = append(append(hooks, s.hooks...), h)
+}
+
+func (s *hookSet) fire(e *entry) {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	if s.closed {
+		return
+	}
+
+	var alert *Alert
+
+	for _, h := range s.hooks {
+		if e.level > h.level {
+			continue
+		}
+
+		if alert == nil {
+			alert = &Alert{Level: e.level, Time: e.time, Caller: e.file + ":" + strconv.Itoa(e.line), Template: e.template, Message: e.msg, Fields: e.fields, Count: 1}
+		}
+
+		h.fire(alert)
+	}
+}
+
+func (s *hookSet) close() (err error) {
+	s.locker.Lock()
+
+	if s.closed {
+		s.locker.Unlock()
+		return
+	}
+
+	s.closed = true
+	hooks := s.hooks
+	s.locker.Unlock()
+
+	for _, h := range hooks {
+		if e := h.close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// AddHook sends every line at level or more severe to notifier, for the logger and every logger derived from it with With.
+func (l *Logger) AddHook(level Level, notifier Notifier) {
+	l.hooks.add(newHook(level, notifier))
+}
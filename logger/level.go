@@ -1,5 +1,10 @@
 package logger
 
+import (
+	"sync"
+	"sync/atomic"
+)
+
 type Level byte
 
 const (
@@ -60,20 +65,33 @@ func (m *LevelMeta) Text(enableColor bool) string {
 	return m.RawText
 }
 
-func GetLevel(levelName string) Level {
+func ParseLevel(levelName string) (Level, bool) {
 	for level, meta := range Levels {
 		if meta.Name == levelName {
-			return level
+			return level, true
 		}
 
 		for _, altName := range meta.Alias {
 			if altName == levelName {
-				return level
+				return level, true
 			}
 		}
 	}
 
-	return DisableLevel
+	return DisableLevel, false
+}
+
+func GetLevel(levelName string) Level {
+	level, _ := ParseLevel(levelName)
+	return level
+}
+
+func (l Level) String() string {
+	if meta, ok := Levels[l]; ok {
+		return meta.Name
+	}
+
+	return ""
 }
 
 func GetLevelText(level Level, enableColor bool) string {
@@ -83,3 +101,99 @@ func GetLevelText(level Level, enableColor bool) string {
 
 	return ""
 }
+
+// levelSet holds the global level and per-module overrides, shared by a logger and its With children.
+type levelSet struct {
+	level   int32
+	max     int32
+	count   int32
+	locker  sync.RWMutex
+	modules map[string]Level
+}
+
+func newLevelSet(level Level, modules map[string]string) *levelSet {
+	s := &levelSet{level: int32(level), modules: make(map[string]Level, len(modules))}
+
+	for module, name := range modules {
+		s.modules[module] = GetLevel(name)
+	}
+
+	s.refresh()
+	return s
+}
+
+func (s *levelSet) refresh() {
+	max := atomic.LoadInt32(&s.level)
+
+	for _, level := range s.modules {
+		if int32(level) > max {
+			max = int32(level)
+		}
+	}
+
+	atomic.StoreInt32(&s.max, max)
+	atomic.StoreInt32(&s.count, int32(len(s.modules)))
+}
+
+func (s *levelSet) get() Level {
+	return Level(atomic.LoadInt32(&s.level))
+}
+
+func (s *levelSet) set(level Level) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	atomic.StoreInt32(&s.level, int32(level))
+	s.refresh()
+}
+
+func (s *levelSet) setModule(module string, level Level) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.modules[module] = level
+	s.refresh()
+}
+
+func (s *levelSet) deleteModule(module string) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	delete(s.modules, module)
+	s.refresh()
+}
+
+func (s *levelSet) getModules() map[string]Level {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	modules := make(map[string]Level, len(s.modules))
+
+	for module, level := range s.modules {
+		modules[module] = level
+	}
+
+	return modules
+}
+
+// maybe is a cheap pre-check before the caller is known: no module allows a more verbose level.
+func (s *levelSet) maybe(level Level) bool {
+	return int32(level) <= atomic.LoadInt32(&s.max)
+}
+
+// hasModules reports whether the caller's module has to be looked up to decide.
+func (s *levelSet) hasModules() bool {
+	return atomic.LoadInt32(&s.count) > 0
+}
+
+func (s *levelSet) allow(level Level, module string) bool {
+	s.locker.RLock()
+	moduleLevel, ok := s.modules[module]
+	s.locker.RUnlock()
+
+	if ok {
+		return level <= moduleLevel
+	}
+
+	return level <= s.get()
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type levelState struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+func (l *Logger) levelState() *levelState {
+	modules := l.ModuleLevels()
+	state := &levelState{Level: l.Level().String(), Modules: make(map[string]string, len(modules))}
+
+	for module, level := range modules {
+		state.Modules[module] = level.String()
+	}
+
+	return state
+}
+
+// LevelHandler shows the levels on GET and changes them on PUT or POST with a body like
+// {"level": "info", "modules": {"scheduler": "debug", "kafka": ""}}, where an empty module level removes the override.
+func (l *Logger) LevelHandler(ctx *gin.Context) {
+	if ctx.Request.Method == http.MethodGet {
+		ctx.JSON(http.StatusOK, gin.H{"code": 0, "message": "success", "data": l.levelState()})
+		return
+	}
+
+	req := &levelState{}
+
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 1, "message": err.Error()})
+		return
+	}
+
+	level, ok := ParseLevel(req.Level)
+
+	if req.Level != "" && !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"code": 1, "message": fmt.Sprintf("invalid level '%s'", req.Level)})
+		return
+	}
+
+	modules := make(map[string]Level, len(req.Modules))
+
+	for module, name := range req.Modules {
+		if name == "" {
+			continue
+		}
+
+		moduleLevel, ok := ParseLevel(name)
+
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"code": 1, "message": fmt.Sprintf("invalid level '%s' of module '%s'", name, module)})
+			return
+		}
+
+		modules[module] = moduleLevel
+	}
+
+	if req.Level != "" {
+		l.SetLevel(level)
+	}
+
+	for module, name := range req.Modules {
+		if name == "" {
+			l.DeleteModuleLevel(module)
+		} else {
+			l.SetModuleLevel(module, modules[module])
+		}
+	}
+
+	l.Warningf("log level changed | level: %s | modules: %+v", req.Level, req.Modules)
+	ctx.JSON(http.StatusOK, gin.H{"code": 0, "message": "success", "data": l.levelState()})
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -12,8 +13,11 @@ import (
 	"time"
 
 	"github.com/marsmay/golib/net2"
+	"github.com/marsmay/golib/trace"
 )
 
+const FieldTraceId = "trace_id"
+
 var localIp string
 var sourceDir string
 
@@ -27,39 +31,55 @@ func init() {
 }
 
 type Config struct {
-	Dir        string `toml:"dir" json:"dir"`
-	Prefix     string `toml:"prefix" json:"prefix"`
-	Level      string `toml:"level" json:"level"`
-	Color      bool   `toml:"color" json:"color"`
-	Terminal   bool   `toml:"terminal" json:"terminal"`
-	ShowIp     bool   `toml:"show_ip" json:"show_ip"`
-	UseUtc     bool   `toml:"use_utc" json:"use_utc"`
-	TimeFormat string `toml:"time_format" json:"time_format"`
+	Dir         string            `toml:"dir" json:"dir"`
+	Prefix      string            `toml:"prefix" json:"prefix"`
+	Level       string            `toml:"level" json:"level"`
+	Color       bool              `toml:"color" json:"color"`
+	Terminal    bool              `toml:"terminal" json:"terminal"`
+	ShowIp      bool              `toml:"show_ip" json:"show_ip"`
+	UseUtc      bool              `toml:"use_utc" json:"use_utc"`
+	TimeFormat  string            `toml:"time_format" json:"time_format"`
+	Format      string            `toml:"format" json:"format"`
+	Modules     map[string]string `toml:"modules" json:"modules"`
+	MaxFileSize int64             `toml:"max_file_size" json:"max_file_size"`
+	Sinks       []*SinkConfig     `toml:"sinks" json:"sinks"`
+	Webhooks    []*WebhookConfig  `toml:"webhooks" json:"webhooks"`
+	FatalAction string            `toml:"fatal_action" json:"fatal_action"`
+	Retention
+	Queue
+	Sampling
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		Dir:        "./logs",
-		Level:      "debug",
-		Color:      true,
-		Terminal:   true,
-		ShowIp:     false,
-		UseUtc:     false,
-		TimeFormat: "2006-01-02T15:04:05.999Z07:00",
+		Dir:         "./logs",
+		Level:       "debug",
+		Color:       true,
+		Terminal:    true,
+		ShowIp:      false,
+		UseUtc:      false,
+		TimeFormat:  "2006-01-02T15:04:05.999Z07:00",
+		Format:      FormatText,
+		FatalAction: FatalLog,
 	}
 }
 
 type Logger struct {
 	conf     *Config
-	writer   io.WriteCloser
-	level    Level
+	sinks    *sinkSet
+	hooks    *hookSet
+	levels   *levelSet
+	sampler  *sampler
+	fields   []interface{}
 	bytePool *sync.Pool
 }
 
 func NewLogger(conf *Config) (l *Logger, err error) {
 	l = &Logger{
-		conf:  conf,
-		level: GetLevel(conf.Level),
+		conf:   conf,
+		sinks:  &sinkSet{},
+		hooks:  &hookSet{},
+		levels: newLevelSet(GetLevel(conf.Level), conf.Modules),
 		bytePool: &sync.Pool{
 			New: func() interface{} {
 				return new(bytes.Buffer)
@@ -67,10 +87,66 @@ func NewLogger(conf *Config) (l *Logger, err error) {
 		},
 	}
 
-	if l.conf.Terminal {
-		l.writer = os.Stdout
-	} else {
-		l.writer, err = newAsyncWriter(conf.Dir, l.getFile)
+	if err = l.openSinks(); err != nil {
+		return
+	}
+
+	for _, wc := range conf.Webhooks {
+		level := ErrorLevel
+
+		if wc.Level != "" {
+			var ok bool
+
+			if level, ok = ParseLevel(wc.Level); !ok {
+				err = fmt.Errorf("invalid webhook level '%s'", wc.Level)
+				_ = l.Close()
+				return
+			}
+		}
+
+		var w *Webhook
+
+		if w, err = NewWebhook(wc); err != nil {
+			_ = l.Close()
+			return
+		}
+
+		l.AddHook(level, w)
+	}
+
+	if conf.Sampling.enabled() {
+		l.sampler = newSampler(&conf.Sampling, l.write)
+	}
+
+	return
+}
+
+func (l *Logger) openSinks() (err error) {
+	conf := l.conf
+
+	// without sinks the logger writes where it always did: the terminal or the daily file under Dir
+	if len(conf.Sinks) == 0 {
+		var w io.Writer
+
+		if conf.Terminal {
+			w = nopCloser{os.Stdout}
+		} else if w, err = newAsyncWriter(conf.Dir, conf.Prefix, l.getFile, &writerOptions{retention: &conf.Retention, maxFileSize: conf.MaxFileSize, queue: &conf.Queue}); err != nil {
+			return
+		}
+
+		l.sinks.add(NewSink(w, DebugLevel, conf.Format, conf.Color, conf))
+		return
+	}
+
+	for _, sc := range conf.Sinks {
+		var sink *Sink
+
+		if sink, err = l.newSink(sc); err != nil {
+			_ = l.sinks.close()
+			return
+		}
+
+		l.sinks.add(sink)
 	}
 
 	return
@@ -86,15 +162,19 @@ func (l *Logger) now() time.Time {
 	return t
 }
 
+func (l *Logger) periodFile(dir, prefix string) string {
+	return path.Join(dir, prefix+l.now().Format("20060102.log"))
+}
+
 func (l *Logger) getFile() string {
-	return path.Join(l.conf.Dir, l.conf.Prefix+l.now().Format("20060102.log"))
+	return l.periodFile(l.conf.Dir, l.conf.Prefix)
 }
 
 func (l *Logger) getFileInfo() (file string, line int) {
 	for i := 2; i < 15; i++ {
 		_, f, n, ok := runtime.Caller(i)
 
-		if ok && (!strings.HasPrefix(f, sourceDir) || strings.HasSuffix(f, "_test.go")) {
+		if ok && (!strings.HasPrefix(f, sourceDir) || strings.HasSuffix(f, "_test.go")) && !skipCaller(f) {
 			if items := strings.Split(f, "/"); len(items) >= 2 {
 				return items[len(items)-2] + "/" + items[len(items)-1], n
 			}
@@ -106,7 +186,8 @@ func (l *Logger) getFileInfo() (file string, line int) {
 	return "???", 0
 }
 
-func (l *Logger) format(level Level, format string, args ...interface{}) []byte {
+// writeSink encodes e into a pooled buffer, sinks follow the io.Writer contract and don't keep it.
+func (l *Logger) writeSink(sink *Sink, e *entry) error {
 	w := l.bytePool.Get().(*bytes.Buffer)
 
 	defer func() {
@@ -114,86 +195,167 @@ func (l *Logger) format(level Level, format string, args ...interface{}) []byte
 		l.bytePool.Put(w)
 	}()
 
-	if l.conf.ShowIp && localIp != "" {
-		w.WriteByte('(')
-		w.WriteString(localIp)
-		w.WriteString(") ")
+	sink.encoder.encode(w, e)
+	return sink.write(e.level, w.Bytes())
+}
+
+// Write copies p unformatted to every sink.
+func (l *Logger) Write(p []byte) (n int, err error) {
+	for _, sink := range l.sinks.list() {
+		if e := sink.write(InfoLevel, p); e != nil && err == nil {
+			err = e
+		}
 	}
 
-	w.WriteString(GetLevelText(level, l.conf.Color))
-	w.WriteByte(' ')
-	w.WriteString(time.Now().Format(l.conf.TimeFormat))
-	w.WriteByte(' ')
+	return len(p), err
+}
 
+func (l *Logger) enabled(level Level) bool {
+	return l.levels.maybe(level)
+}
+
+// output writes one line, template is the format or message the line is sampled on.
+func (l *Logger) output(level Level, template, msg string, fields []interface{}) {
 	file, line := l.getFileInfo()
 
-	if s := fmt.Sprintf("<%s:%d> ", file, line); l.conf.Color {
-		w.WriteString(Blue(s))
-	} else {
-		w.WriteString(s)
+	// module overrides are keyed on the package directory of the caller, e.g. "scheduler" for scheduler/worker.go
+	if l.levels.hasModules() && !l.levels.allow(level, path.Dir(file)) {
+		return
+	}
+
+	if l.sampler != nil && !l.sampler.allow(level, template, file, line) {
+		return
+	}
+
+	l.write(level, file, line, template, msg, fields)
+}
+
+func (l *Logger) write(level Level, file string, line int, template, msg string, fields []interface{}) {
+	e := &entry{level: level, time: l.now(), file: file, line: line, template: template, msg: msg, fields: l.fields}
+
+	if len(fields) > 0 {
+		e.fields = append(e.fields[:len(e.fields):len(e.fields)], fields...)
+	}
+
+	for _, sink := range l.sinks.list() {
+		if level <= sink.level {
+			_ = l.writeSink(sink, e)
+		}
+	}
+
+	l.hooks.fire(e)
+}
+
+func (l *Logger) Log(level Level, format string, args ...interface{}) {
+	if !l.enabled(level) {
+		return
 	}
 
 	if len(format) == 0 {
-		_, _ = fmt.Fprint(w, args...)
+		msg := fmt.Sprint(args...)
+		l.output(level, msg, msg, nil)
 	} else {
-		_, _ = fmt.Fprintf(w, format, args...)
+		l.output(level, format, fmt.Sprintf(format, args...), nil)
 	}
+}
 
-	w.WriteByte('\n')
+func (l *Logger) print(level Level, args []interface{}) {
+	if l.enabled(level) {
+		msg := fmt.Sprint(args...)
+		l.output(level, msg, msg, nil)
+	}
+}
 
-	b := make([]byte, w.Len())
-	copy(b, w.Bytes())
-	return b
+// Logw writes msg with alternating key-value pairs, e.g. l.Logw(InfoLevel, "user login", "uid", 1001).
+func (l *Logger) Logw(level Level, msg string, kv ...interface{}) {
+	if l.enabled(level) {
+		l.output(level, msg, msg, kv)
+	}
 }
 
-func (l *Logger) Write(p []byte) (n int, err error) {
-	return l.writer.Write(p)
+// With returns a logger that adds the key-value pairs to every line; it shares the sinks with l.
+func (l *Logger) With(kv ...interface{}) *Logger {
+	child := *l
+	child.fields = append(l.fields[:len(l.fields):len(l.fields)], kv...)
+	return &child
 }
 
-func (l *Logger) Log(level Level, format string, args ...interface{}) {
-	if level <= l.level {
-		_, _ = l.writer.Write(l.format(level, format, args...))
+// WithContext returns a logger that writes the trace id of ctx (see trace.GetTraceId) on every line.
+// A gin.Context can be passed directly once trace.GinTraceHandler has run.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		return l
+	}
+
+	if traceId, ok := trace.LookupTraceId(ctx); ok {
+		return l.With(FieldTraceId, traceId)
 	}
+
+	return l
 }
 
 func (l *Logger) Debug(args ...interface{}) {
-	l.Log(DebugLevel, "", args...)
+	l.print(DebugLevel, args)
 }
 
 func (l *Logger) Debugf(format string, args ...interface{}) {
 	l.Log(DebugLevel, format, args...)
 }
 
+func (l *Logger) Debugw(msg string, kv ...interface{}) {
+	l.Logw(DebugLevel, msg, kv...)
+}
+
 func (l *Logger) Info(args ...interface{}) {
-	l.Log(InfoLevel, "", args...)
+	l.print(InfoLevel, args)
 }
 
 func (l *Logger) Infof(format string, args ...interface{}) {
 	l.Log(InfoLevel, format, args...)
 }
 
+func (l *Logger) Infow(msg string, kv ...interface{}) {
+	l.Logw(InfoLevel, msg, kv...)
+}
+
 func (l *Logger) Warning(args ...interface{}) {
-	l.Log(WarnLevel, "", args...)
+	l.print(WarnLevel, args)
 }
 
 func (l *Logger) Warningf(format string, args ...interface{}) {
 	l.Log(WarnLevel, format, args...)
 }
 
+func (l *Logger) Warningw(msg string, kv ...interface{}) {
+	l.Logw(WarnLevel, msg, kv...)
+}
+
 func (l *Logger) Error(args ...interface{}) {
-	l.Log(ErrorLevel, "", args...)
+	l.print(ErrorLevel, args)
 }
 
 func (l *Logger) Errorf(format string, args ...interface{}) {
 	l.Log(ErrorLevel, format, args...)
 }
 
+func (l *Logger) Errorw(msg string, kv ...interface{}) {
+	l.Logw(ErrorLevel, msg, kv...)
+}
+
+// Fatal logs at fatal level, then exits the process when Config.FatalAction is "exit".
 func (l *Logger) Fatal(args ...interface{}) {
-	l.Log(FatalLevel, "", args...)
+	l.print(FatalLevel, args)
+	l.fatal()
 }
 
 func (l *Logger) Fatalf(format string, args ...interface{}) {
 	l.Log(FatalLevel, format, args...)
+	l.fatal()
+}
+
+func (l *Logger) Fatalw(msg string, kv ...interface{}) {
+	l.Logw(FatalLevel, msg, kv...)
+	l.fatal()
 }
 
 func (l *Logger) Config() *Config {
@@ -201,9 +363,53 @@ func (l *Logger) Config() *Config {
 }
 
 func (l *Logger) Level() Level {
-	return l.level
+	return l.levels.get()
+}
+
+// SetLevel changes the level of the logger and of every logger derived from it with With.
+func (l *Logger) SetLevel(level Level) {
+	l.levels.set(level)
+}
+
+// SetModuleLevel overrides the level for callers in one package directory, e.g. "scheduler".
+func (l *Logger) SetModuleLevel(module string, level Level) {
+	l.levels.setModule(module, level)
+}
+
+func (l *Logger) DeleteModuleLevel(module string) {
+	l.levels.deleteModule(module)
+}
+
+func (l *Logger) ModuleLevels() map[string]Level {
+	return l.levels.getModules()
 }
 
-func (l *Logger) Close() {
-	_ = l.writer.Close()
+// Stats sums the queues of the async file and syslog sinks, it is empty when only writing to the terminal.
+func (l *Logger) Stats() (stats WriterStats) {
+	for _, sink := range l.sinks.list() {
+		if w, ok := sink.writer.(interface{ Stats() WriterStats }); ok {
+			s := w.Stats()
+			stats.Dropped += s.Dropped
+			stats.Blocked += s.Blocked
+			stats.Errors += s.Errors
+			stats.Queued += s.Queued
+			stats.Capacity += s.Capacity
+		}
+	}
+
+	return
+}
+
+func (l *Logger) Close() error {
+	if l.sampler != nil {
+		l.sampler.close()
+	}
+
+	err := l.hooks.close()
+
+	if e := l.sinks.close(); e != nil {
+		err = e
+	}
+
+	return err
 }
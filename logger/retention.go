@@ -0,0 +1,187 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	compressSuffix  = ".gz"
+	janitorInterval = time.Minute
+)
+
+var rotatedPattern = regexp.MustCompile(`^\d{8}(\.\d+)*\.log(\.gz)?$`)
+
+type Retention struct {
+	MaxAge   int   `toml:"max_age" json:"max_age"`
+	MaxFiles int   `toml:"max_files" json:"max_files"`
+	MaxSize  int64 `toml:"max_size" json:"max_size"`
+	Compress bool  `toml:"compress" json:"compress"`
+}
+
+// enabled reports whether any limit is set; MaxAge is in days and MaxSize in megabytes.
+func (r *Retention) enabled() bool {
+	return r != nil && (r.MaxAge > 0 || r.MaxFiles > 0 || r.MaxSize > 0 || r.Compress)
+}
+
+type logFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func compressFile(file string) (err error) {
+	src, err := os.Open(file)
+
+	if err != nil {
+		return
+	}
+
+	defer func() { _ = src.Close() }()
+
+	tmpFile := file + compressSuffix + ".tmp"
+	dst, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return
+	}
+
+	gz := gzip.NewWriter(dst)
+
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+
+	if e := dst.Close(); err == nil {
+		err = e
+	}
+
+	if err == nil {
+		err = os.Rename(tmpFile, file+compressSuffix)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		return
+	}
+
+	return os.Remove(file)
+}
+
+// rotated lists the files written by this writer except the one still open, oldest first.
+func (l *asyncWriter) rotated() (files []*logFile, err error) {
+	infos, err := ioutil.ReadDir(l.dir)
+
+	if err != nil {
+		return
+	}
+
+	current := l.current()
+
+	for _, info := range infos {
+		name := info.Name()
+
+		if info.IsDir() || !strings.HasPrefix(name, l.prefix) || !rotatedPattern.MatchString(name[len(l.prefix):]) {
+			continue
+		}
+
+		file := filepath.Join(l.dir, name)
+
+		if filepath.Clean(file) == filepath.Clean(current) {
+			continue
+		}
+
+		files = append(files, &logFile{path: file, size: info.Size(), modTime: info.ModTime()})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	return
+}
+
+func (l *asyncWriter) cleanup() {
+	files, err := l.rotated()
+
+	if err != nil {
+		l.reportErr(err)
+		return
+	}
+
+	if l.retention.Compress {
+		for _, f := range files {
+			if strings.HasSuffix(f.path, compressSuffix) {
+				continue
+			}
+
+			if err = compressFile(f.path); err != nil {
+				l.reportErr(err)
+				continue
+			}
+
+			f.path += compressSuffix
+
+			if info, e := os.Stat(f.path); e == nil {
+				f.size = info.Size()
+			}
+		}
+	}
+
+	var totalSize int64
+
+	for _, f := range files {
+		totalSize += f.size
+	}
+
+	if info, e := os.Stat(l.current()); e == nil {
+		totalSize += info.Size()
+	}
+
+	deadline := time.Now().Add(-time.Duration(l.retention.MaxAge) * 24 * time.Hour)
+	maxSize := l.retention.MaxSize * 1024 * 1024
+
+	for i, f := range files {
+		expired := l.retention.MaxAge > 0 && f.modTime.Before(deadline)
+		tooMany := l.retention.MaxFiles > 0 && len(files)-i > l.retention.MaxFiles
+		tooLarge := maxSize > 0 && totalSize > maxSize
+
+		if !expired && !tooMany && !tooLarge {
+			continue
+		}
+
+		if err = os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			l.reportErr(err)
+			continue
+		}
+
+		totalSize -= f.size
+	}
+}
+
+func (l *asyncWriter) janitor() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+
+	l.cleanup()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+			l.cleanup()
+		case <-l.rotateSign:
+			l.cleanup()
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultSampleInterval = 1000
+	suppressedTemplate    = "suppressed %d similar messages"
+)
+
+// Sampling keeps the first SampleFirst lines of each message template per SampleInterval milliseconds,
+// then one line in SampleThereafter (none when it is 0). Fatal lines are never sampled. At the end of
+// each interval a template that lost lines writes "suppressed N similar messages" at its own level.
+type Sampling struct {
+	SampleFirst      int           `toml:"sample_first" json:"sample_first"`
+	SampleThereafter int           `toml:"sample_thereafter" json:"sample_thereafter"`
+	SampleInterval   time.Duration `toml:"sample_interval" json:"sample_interval"`
+}
+
+func (s *Sampling) enabled() bool {
+	return s != nil && s.SampleFirst > 0
+}
+
+type sampleCounter struct {
+	level      Level
+	file       string
+	line       int
+	count      int64
+	suppressed int64
+}
+
+// sampler is shared by a logger and its With children, counters are swapped out at every interval.
+type sampler struct {
+	first      int64
+	thereafter int64
+	interval   time.Duration
+	locker     sync.RWMutex
+	counters   map[string]*sampleCounter
+	report     func(level Level, file string, line int, template, msg string, fields []interface{})
+	stop       chan bool
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+}
+
+func newSampler(s *Sampling, report func(level Level, file string, line int, template, msg string, fields []interface{})) *sampler {
+	interval := s.SampleInterval
+
+	if interval <= 0 {
+		interval = defaultSampleInterval
+	}
+
+	sp := &sampler{
+		first:      int64(s.SampleFirst),
+		thereafter: int64(s.SampleThereafter),
+		interval:   interval * time.Millisecond,
+		counters:   make(map[string]*sampleCounter),
+		report:     report,
+		stop:       make(chan bool),
+	}
+
+	sp.wg.Add(1)
+	go sp.run()
+	return sp
+}
+
+func (s *sampler) counter(level Level, template, file string, line int) *sampleCounter {
+	s.locker.RLock()
+	c, ok := s.counters[template]
+	s.locker.RUnlock()
+
+	if ok {
+		return c
+	}
+
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	if c, ok = s.counters[template]; !ok {
+		c = &sampleCounter{level: level, file: file, line: line}
+		s.counters[template] = c
+	}
+
+	return c
+}
+
+func (s *sampler) allow(level Level, template, file string, line int) bool {
+	if level == FatalLevel {
+		return true
+	}
+
+	c := s.counter(level, template, file, line)
+	n := atomic.AddInt64(&c.count, 1)
+
+	if n <= s.first || (s.thereafter > 0 && (n-s.first)%s.thereafter == 0) {
+		return true
+	}
+
+	atomic.AddInt64(&c.suppressed, 1)
+	return false
+}
+
+func (s *sampler) flush() {
+	s.locker.Lock()
+	counters := s.counters
+	s.counters = make(map[string]*sampleCounter, len(counters))
+	s.locker.Unlock()
+
+	for template, c := range counters {
+		if n := atomic.LoadInt64(&c.suppressed); n > 0 {
+			s.report(c.level, c.file, c.line, suppressedTemplate, fmt.Sprintf(suppressedTemplate, n), []interface{}{"template", template})
+		}
+	}
+}
+
+func (s *sampler) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			s.flush()
+			return
+		case <-ticker.C:
+			s.flush()
+		}
+	}
+}
+
+// close reports the suppressed lines of the current interval, so nothing is lost silently.
+func (s *sampler) close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.wg.Wait()
+}
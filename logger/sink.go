@@ -0,0 +1,195 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	SinkFile   = "file"
+	SinkStdout = "stdout"
+	SinkStderr = "stderr"
+	SinkSyslog = "syslog"
+)
+
+// SinkConfig describes one output of a logger. Format defaults to the logger's own, a sink without
+// Level writes every line the logger lets through, and a sink level can only make the output quieter.
+type SinkConfig struct {
+	Type     string `toml:"type" json:"type"`
+	Level    string `toml:"level" json:"level"`
+	Format   string `toml:"format" json:"format"`
+	Color    bool   `toml:"color" json:"color"`
+	Dir      string `toml:"dir" json:"dir"`
+	Prefix   string `toml:"prefix" json:"prefix"`
+	Network  string `toml:"network" json:"network"`
+	Addr     string `toml:"addr" json:"addr"`
+	Tag      string `toml:"tag" json:"tag"`
+	Facility int    `toml:"facility" json:"facility"`
+}
+
+// LevelWriter is implemented by outputs that need the level of every line, e.g. syslog for the priority.
+type LevelWriter interface {
+	WriteLevel(level Level, p []byte) (n int, err error)
+}
+
+type Sink struct {
+	writer  io.WriteCloser
+	level   Level
+	encoder encoder
+}
+
+// NewSink wraps any writer as a logger output, a writer that isn't an io.Closer is never closed.
+// Writes are serialized unless the writer is one of the logger's own, e.g. a bytes.Buffer is safe
+// to use even though callers and the sampler write from different goroutines.
+func NewSink(w io.Writer, level Level, format string, color bool, conf *Config) *Sink {
+	c := *conf
+	c.Format, c.Color = format, color
+
+	if c.Format == "" {
+		c.Format = conf.Format
+	}
+
+	var wc io.WriteCloser
+
+	switch v := w.(type) {
+	case *asyncWriter:
+		wc = v
+	case *syslogWriter:
+		wc = v
+	default:
+		wc = &lockedWriter{w: w}
+	}
+
+	return &Sink{writer: wc, level: level, encoder: newEncoder(&c)}
+}
+
+func (s *Sink) write(level Level, p []byte) (err error) {
+	if lw, ok := s.writer.(LevelWriter); ok {
+		_, err = lw.WriteLevel(level, p)
+	} else {
+		_, err = s.writer.Write(p)
+	}
+
+	return
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// lockedWriter serializes the writes of a writer that isn't known to be safe for concurrent use.
+type lockedWriter struct {
+	locker sync.Mutex
+	w      io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	return l.w.Write(p)
+}
+
+func (l *lockedWriter) WriteLevel(level Level, p []byte) (int, error) {
+	l.locker.Lock()
+	defer l.locker.Unlock()
+
+	if lw, ok := l.w.(LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+
+	return l.w.Write(p)
+}
+
+func (l *lockedWriter) Close() error {
+	if c, ok := l.w.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// sinkSet is shared by a logger and its With children, sinks can be added while logging.
+type sinkSet struct {
+	locker sync.RWMutex
+	sinks  []*Sink
+}
+
+func (s *sinkSet) add(sink *Sink) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	sinks := make([]*Sink, 0, len(s.sinks)+1)
+	s.sinks = append(append(sinks, s.sinks...), sink)
+}
+
+func (s *sinkSet) list() []*Sink {
+	s.locker.RLock()
+	defer s.locker.RUnlock()
+
+	return s.sinks
+}
+
+func (s *sinkSet) close() (err error) {
+	for _, sink := range s.list() {
+		if e := sink.writer.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+func (l *Logger) newSink(sc *SinkConfig) (sink *Sink, err error) {
+	level := DebugLevel
+
+	if sc.Level != "" {
+		var ok bool
+
+		if level, ok = ParseLevel(sc.Level); !ok {
+			return nil, fmt.Errorf("invalid sink level '%s'", sc.Level)
+		}
+	}
+
+	var w io.Writer
+
+	switch sc.Type {
+	case SinkStdout:
+		w = nopCloser{os.Stdout}
+	case SinkStderr:
+		w = nopCloser{os.Stderr}
+	case SinkFile:
+		dir, prefix := sc.Dir, sc.Prefix
+
+		if dir == "" {
+			dir, prefix = l.conf.Dir, l.conf.Prefix
+		}
+
+		getFile := func() string {
+			return l.periodFile(dir, prefix)
+		}
+
+		w, err = newAsyncWriter(dir, prefix, getFile, &writerOptions{retention: &l.conf.Retention, maxFileSize: l.conf.MaxFileSize, queue: &l.conf.Queue})
+	case SinkSyslog:
+		w = newSyslogWriter(sc.Network, sc.Addr, sc.Tag, sc.Facility, &l.conf.Queue)
+	default:
+		err = fmt.Errorf("invalid sink type '%s'", sc.Type)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return NewSink(w, level, sc.Format, sc.Color, l.conf), nil
+}
+
+// AddSink adds an output to the logger and to every logger derived from it with With.
+func (l *Logger) AddSink(sink *Sink) {
+	l.sinks.add(sink)
+}
@@ -0,0 +1,253 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	syslogTimeFormat  = "2006-01-02T15:04:05.000000Z07:00"
+	syslogDialTimeout = 3 * time.Second
+	syslogMinBackoff  = time.Second
+	syslogMaxBackoff  = time.Minute
+	facilityUser      = 1
+)
+
+var syslogSeverities = map[Level]int{
+	FatalLevel: 2,
+	ErrorLevel: 3,
+	WarnLevel:  4,
+	InfoLevel:  6,
+	DebugLevel: 7,
+}
+
+// syslogWriter sends RFC 5424 messages over udp, tcp, tcp4 or tcp6 (octet-counting framing) or a unix socket.
+// Lines go through a queue with the logger's Queue policy, so a slow or dead collector never blocks
+// logging callers; while the collector is unreachable lines are dropped and reconnects back off.
+type syslogWriter struct {
+	network  string
+	addr     string
+	tag      string
+	hostname string
+	facility int
+	conn     net.Conn
+	backoff  time.Duration
+	retryAt  time.Time
+	queue    chan syslogLine
+	policy   string
+	timeout  time.Duration
+	locker   sync.RWMutex
+	closed   bool
+	dropped  int64
+	blocked  int64
+	errors   int64
+	end      chan bool
+}
+
+type syslogLine struct {
+	level Level
+	time  time.Time
+	msg   []byte
+}
+
+func newSyslogWriter(network, addr, tag string, facility int, q *Queue) (w *syslogWriter) {
+	if network == "" {
+		network = "udp"
+	}
+
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+
+	if facility <= 0 {
+		facility = facilityUser
+	}
+
+	w = &syslogWriter{network: network, addr: addr, tag: tag, facility: facility, end: make(chan bool)}
+
+	var err error
+
+	if w.hostname, err = os.Hostname(); err != nil {
+		w.hostname = "-"
+	}
+
+	// a collector that is down at startup doesn't stop the logger, the queue goroutine reconnects
+	w.reconnect()
+
+	queueSize := defaultQueueSize
+
+	if q != nil {
+		if q.QueueSize > 0 {
+			queueSize = q.QueueSize
+		}
+
+		w.policy, w.timeout = q.QueuePolicy, q.QueueTimeout*time.Millisecond
+	}
+
+	w.queue = make(chan syslogLine, queueSize)
+	go w.start()
+
+	return
+}
+
+func (w *syslogWriter) connect() (err error) {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	if w.network == "unix" {
+		// syslog daemons listen on a datagram socket, fall back to a stream one
+		if w.conn, err = net.DialTimeout("unixgram", w.addr, syslogDialTimeout); err == nil {
+			return
+		}
+	}
+
+	w.conn, err = net.DialTimeout(w.network, w.addr, syslogDialTimeout)
+	return
+}
+
+// reconnect dials unless a recent failure asks to wait, each failure doubles the wait up to a limit.
+func (w *syslogWriter) reconnect() bool {
+	if time.Now().Before(w.retryAt) {
+		return false
+	}
+
+	err := w.connect()
+
+	if err == nil {
+		w.backoff = 0
+		return true
+	}
+
+	atomic.AddInt64(&w.errors, 1)
+
+	if w.backoff == 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "logger: syslog unreachable, dropping lines | addr: %s | error: %s\n", w.addr, err)
+	}
+
+	if w.backoff *= 2; w.backoff < syslogMinBackoff {
+		w.backoff = syslogMinBackoff
+	} else if w.backoff > syslogMaxBackoff {
+		w.backoff = syslogMaxBackoff
+	}
+
+	w.retryAt = time.Now().Add(w.backoff)
+	return false
+}
+
+func (w *syslogWriter) message(line syslogLine) []byte {
+	severity, ok := syslogSeverities[line.level]
+
+	if !ok {
+		severity = syslogSeverities[InfoLevel]
+	}
+
+	b := new(bytes.Buffer)
+	_, _ = fmt.Fprintf(b, "<%d>1 %s %s %s %d - - ", w.facility*8+severity, line.time.Format(syslogTimeFormat), w.hostname, w.tag, os.Getpid())
+	b.Write(bytes.TrimRight(line.msg, "\n"))
+
+	if strings.HasPrefix(w.network, "tcp") {
+		return append([]byte(strconv.Itoa(b.Len())+" "), b.Bytes()...)
+	}
+
+	return b.Bytes()
+}
+
+// write sends one message, a stalled stream collector fails it after the dial timeout.
+func (w *syslogWriter) write(msg []byte) bool {
+	_ = w.conn.SetWriteDeadline(time.Now().Add(syslogDialTimeout))
+
+	if _, err := w.conn.Write(msg); err == nil {
+		return true
+	}
+
+	atomic.AddInt64(&w.errors, 1)
+	_ = w.conn.Close()
+	w.conn = nil
+	return false
+}
+
+func (w *syslogWriter) send(line syslogLine) {
+	msg := w.message(line)
+
+	if w.conn != nil && w.write(msg) {
+		return
+	}
+
+	// the collector may have restarted, so the first failure reconnects right away
+	if w.reconnect() && w.write(msg) {
+		return
+	}
+
+	atomic.AddInt64(&w.dropped, 1)
+}
+
+func (w *syslogWriter) start() {
+	for line := range w.queue {
+		w.send(line)
+	}
+
+	close(w.end)
+}
+
+func (w *syslogWriter) WriteLevel(level Level, p []byte) (n int, err error) {
+	w.locker.RLock()
+	defer w.locker.RUnlock()
+
+	if w.closed {
+		atomic.AddInt64(&w.dropped, 1)
+		return 0, ErrWriterClosed
+	}
+
+	line := syslogLine{level: level, time: time.Now(), msg: make([]byte, len(p))}
+	copy(line.msg, p)
+
+	enqueue(w.queue, line, w.policy, w.timeout, &w.dropped, &w.blocked)
+	return len(p), nil
+}
+
+func (w *syslogWriter) Write(p []byte) (n int, err error) {
+	return w.WriteLevel(InfoLevel, p)
+}
+
+func (w *syslogWriter) Stats() WriterStats {
+	return WriterStats{
+		Dropped:  atomic.LoadInt64(&w.dropped),
+		Blocked:  atomic.LoadInt64(&w.blocked),
+		Errors:   atomic.LoadInt64(&w.errors),
+		Queued:   len(w.queue),
+		Capacity: cap(w.queue),
+	}
+}
+
+// Close sends the queued lines, lines that can't be sent are dropped.
+func (w *syslogWriter) Close() (err error) {
+	w.locker.Lock()
+
+	if w.closed {
+		w.locker.Unlock()
+		return ErrWriterClosed
+	}
+
+	w.closed = true
+	close(w.queue)
+	w.locker.Unlock()
+
+	<-w.end
+
+	if w.conn != nil {
+		err = w.conn.Close()
+		w.conn = nil
+	}
+
+	return
+}
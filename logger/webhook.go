@@ -0,0 +1,315 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	TemplateJson     = "json"
+	TemplateDingTalk = "dingtalk"
+	TemplateFeishu   = "feishu"
+	TemplateSlack    = "slack"
+)
+
+const (
+	defaultWebhookTimeout  = 3000
+	defaultWebhookInterval = 60000
+	defaultRetryInterval   = 500
+)
+
+// WebhookConfig posts alerts as JSON. Template is one of the built-in payloads or a text/template
+// executed with an *Alert and the functions json, text, level and fields, e.g. {"content": {{ text . | json }}}.
+// Within Interval milliseconds only the first alert of a caller and message template is sent, its repeats
+// are counted into one more alert at the end of the interval. Failed posts are retried Retry times.
+type WebhookConfig struct {
+	Url           string            `toml:"url" json:"url"`
+	Level         string            `toml:"level" json:"level"`
+	Template      string            `toml:"template" json:"template"`
+	Title         string            `toml:"title" json:"title"`
+	Headers       map[string]string `toml:"headers" json:"headers"`
+	Timeout       time.Duration     `toml:"timeout" json:"timeout"`
+	Interval      time.Duration     `toml:"interval" json:"interval"`
+	Retry         int               `toml:"retry" json:"retry"`
+	RetryInterval time.Duration     `toml:"retry_interval" json:"retry_interval"`
+}
+
+type alertWindow struct {
+	start      time.Time
+	last       *Alert
+	suppressed int
+}
+
+type Webhook struct {
+	conf     *WebhookConfig
+	client   *http.Client
+	tmpl     *template.Template
+	interval time.Duration
+	locker   sync.Mutex
+	windows  map[string]*alertWindow
+	stop     chan bool
+	wg       sync.WaitGroup
+}
+
+func NewWebhook(conf *WebhookConfig) (w *Webhook, err error) {
+	if conf.Url == "" {
+		return nil, errors.New("webhook url is empty")
+	}
+
+	timeout, interval := conf.Timeout, conf.Interval
+
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	if interval <= 0 {
+		interval = defaultWebhookInterval
+	}
+
+	w = &Webhook{
+		conf:     conf,
+		client:   &http.Client{Timeout: timeout * time.Millisecond},
+		interval: interval * time.Millisecond,
+		windows:  make(map[string]*alertWindow),
+		stop:     make(chan bool),
+	}
+
+	if w.tmpl, err = w.parse(conf.Template); err != nil {
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go w.run()
+	return
+}
+
+func (w *Webhook) parse(name string) (*template.Template, error) {
+	text := name
+
+	switch name {
+	case "", TemplateJson:
+		text = `{"level":{{ level . | json }},"time":{{ .Time | json }},"caller":{{ .Caller | json }},"message":{{ .Message | json }},"fields":{{ fields . | json }},"count":{{ .Count }}}`
+	case TemplateDingTalk:
+		text = `{"msgtype":"text","text":{"content":{{ text . | json }}}}`
+	case TemplateFeishu:
+		text = `{"msg_type":"text","content":{"text":{{ text . | json }}}}`
+	case TemplateSlack:
+		text = `{"text":{{ text . | json }}}`
+	}
+
+	return template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"text":   w.text,
+		"level":  func(a *Alert) string { return a.Level.String() },
+		"fields": alertFields,
+	}).Parse(text)
+}
+
+func alertFields(a *Alert) map[string]string {
+	fields := make(map[string]string, len(a.Fields)/2)
+
+	eachField(a.Fields, func(key string, value interface{}) {
+		fields[key] = fmt.Sprintf("%+v", value)
+	})
+
+	return fields
+}
+
+// text renders an alert for chat bots, one item per line.
+func (w *Webhook) text(a *Alert) string {
+	b := new(strings.Builder)
+
+	if w.conf.Title != "" {
+		b.WriteString(w.conf.Title)
+		b.WriteByte('\n')
+	}
+
+	_, _ = fmt.Fprintf(b, "[%s] %s\n", strings.ToUpper(a.Level.String()), a.Message)
+	_, _ = fmt.Fprintf(b, "time: %s\ncaller: %s", a.Time.Format(time.RFC3339), a.Caller)
+
+	eachField(a.Fields, func(key string, value interface{}) {
+		_, _ = fmt.Fprintf(b, "\n%s: %+v", key, value)
+	})
+
+	if a.Count > 1 {
+		_, _ = fmt.Fprintf(b, "\n%d occurrences in %s", a.Count, w.interval)
+	}
+
+	return b.String()
+}
+
+// Notify sends the first alert of a message template in each interval and counts the others.
+func (w *Webhook) Notify(alert *Alert) error {
+	key := alert.Caller + " " + alert.Template
+	now := time.Now()
+
+	w.locker.Lock()
+	window, ok := w.windows[key]
+
+	if ok && now.Sub(window.start) < w.interval {
+		window.last = alert
+		window.suppressed++
+		w.locker.Unlock()
+		return nil
+	}
+
+	w.windows[key] = &alertWindow{start: now}
+	w.locker.Unlock()
+
+	if ok && window.suppressed > 0 {
+		if err := w.post(w.repeated(window)); err != nil {
+			return err
+		}
+	}
+
+	return w.post(alert)
+}
+
+func (w *Webhook) repeated(window *alertWindow) *Alert {
+	a := *window.last
+	a.Count = window.suppressed
+	return &a
+}
+
+// flush sends the repeats of the intervals that have ended, or of all intervals when force is set.
+func (w *Webhook) flush(force bool) {
+	now := time.Now()
+	var alerts []*Alert
+
+	w.locker.Lock()
+
+	for key, window := range w.windows {
+		if !force && now.Sub(window.start) < w.interval {
+			continue
+		}
+
+		if window.suppressed > 0 {
+			alerts = append(alerts, w.repeated(window))
+		}
+
+		delete(w.windows, key)
+	}
+
+	w.locker.Unlock()
+
+	for _, a := range alerts {
+		if err := w.post(a); err != nil {
+			reportHookErr(err)
+		}
+	}
+}
+
+func (w *Webhook) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			w.flush(true)
+			return
+		case <-ticker.C:
+			w.flush(false)
+		}
+	}
+}
+
+func (w *Webhook) post(alert *Alert) (err error) {
+	body := new(bytes.Buffer)
+
+	if err = w.tmpl.Execute(body, alert); err != nil {
+		return
+	}
+
+	retryInterval := w.conf.RetryInterval
+
+	if retryInterval <= 0 {
+		retryInterval = defaultRetryInterval
+	}
+
+	retryInterval *= time.Millisecond
+
+	for i := 0; ; i++ {
+		if err = w.send(body.Bytes()); err == nil || i >= w.conf.Retry {
+			return
+		}
+
+		time.Sleep(retryInterval)
+		retryInterval *= 2
+	}
+}
+
+type webhookResult struct {
+	Code    *int   `json:"code"`
+	ErrCode *int   `json:"errcode"`
+	Msg     string `json:"msg"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (w *Webhook) send(body []byte) (err error) {
+	req, err := http.NewRequest(http.MethodPost, w.conf.Url, bytes.NewReader(body))
+
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for key, value := range w.conf.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(req)
+
+	if err != nil {
+		return
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	if err != nil {
+		return
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %d: %s", resp.StatusCode, data)
+	}
+
+	// chat bots answer 200 with an error code in the body
+	var result webhookResult
+
+	if json.Unmarshal(data, &result) == nil {
+		if result.ErrCode != nil && *result.ErrCode != 0 {
+			return fmt.Errorf("webhook responded errcode %d: %s", *result.ErrCode, result.ErrMsg)
+		}
+
+		if result.Code != nil && *result.Code != 0 {
+			return fmt.Errorf("webhook responded code %d: %s", *result.Code, result.Msg)
+		}
+	}
+
+	return
+}
+
+// Close sends the repeats still being counted.
+func (w *Webhook) Close() error {
+	close(w.stop)
+	w.wg.Wait()
+	return nil
+}
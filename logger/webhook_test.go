@@ -0,0 +1,212 @@
+package logger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type webhookServer struct {
+	*httptest.Server
+	locker   sync.Mutex
+	bodies   [][]byte
+	headers  []http.Header
+	requests int32
+	respond  func(n int32) string
+}
+
+func newWebhookServer(t *testing.T) *webhookServer {
+	s := &webhookServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&s.requests, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+
+		s.locker.Lock()
+		s.bodies = append(s.bodies, body)
+		s.headers = append(s.headers, r.Header.Clone())
+		s.locker.Unlock()
+
+		if s.respond != nil {
+			_, _ = w.Write([]byte(s.respond(n)))
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) alerts(t *testing.T) (alerts []map[string]interface{}) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	for _, body := range s.bodies {
+		var a map[string]interface{}
+
+		if err := json.Unmarshal(body, &a); err != nil {
+			t.Fatalf("invalid payload %s: %s", body, err)
+		}
+
+		alerts = append(alerts, a)
+	}
+
+	return
+}
+
+func newWebhookLogger(t *testing.T, webhooks ...*WebhookConfig) *Logger {
+	conf := DefaultConfig()
+	conf.Terminal, conf.Dir = false, t.TempDir()
+	conf.Webhooks = webhooks
+
+	l, err := NewLogger(conf)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return l
+}
+
+func TestWebhookPayload(t *testing.T) {
+	s := newWebhookServer(t)
+	l := newWebhookLogger(t, &WebhookConfig{Url: s.URL, Headers: map[string]string{"X-Token": "secret"}})
+
+	l.Info("below the webhook level")
+	l.Errorw("db down", "host", "10.0.0.1")
+	_ = l.Close()
+
+	alerts := s.alerts(t)
+
+	if len(alerts) != 1 {
+		t.Fatalf("posted %d alerts, want 1", len(alerts))
+	}
+
+	a := alerts[0]
+
+	if a["level"] != "error" || a["message"] != "db down" || a["count"] != float64(1) {
+		t.Errorf("unexpected alert %v", a)
+	}
+
+	if caller, _ := a["caller"].(string); !strings.HasPrefix(caller, "logger/webhook_test.go:") {
+		t.Errorf("caller is %q", caller)
+	}
+
+	if fields, _ := a["fields"].(map[string]interface{}); fields["host"] != "10.0.0.1" {
+		t.Errorf("fields are %v", a["fields"])
+	}
+
+	if h := s.headers[0]; h.Get("X-Token") != "secret" || h.Get("Content-Type") != "application/json" {
+		t.Errorf("headers are %v", h)
+	}
+}
+
+func TestWebhookRateLimit(t *testing.T) {
+	s := newWebhookServer(t)
+	l := newWebhookLogger(t, &WebhookConfig{Url: s.URL, Interval: 60000})
+
+	// repeats of a template are counted, even with different arguments
+	for i := 0; i < 5; i++ {
+		l.Errorf("user %d not found", i)
+	}
+
+	l.Errorf("order %d not found", 1)
+	_ = l.Close()
+
+	alerts := s.alerts(t)
+
+	if len(alerts) != 3 {
+		t.Fatalf("posted %d alerts, want 3", len(alerts))
+	}
+
+	counts := map[string]float64{}
+
+	for _, a := range alerts {
+		counts[a["message"].(string)] += a["count"].(float64)
+	}
+
+	if counts["user 0 not found"] != 1 || counts["user 4 not found"] != 4 || counts["order 1 not found"] != 1 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	s := newWebhookServer(t)
+	s.respond = func(n int32) string {
+		if n == 1 {
+			return `{"errcode":310000,"errmsg":"keywords not in content"}`
+		}
+
+		return `{"errcode":0}`
+	}
+
+	w, err := NewWebhook(&WebhookConfig{Url: s.URL, Template: TemplateDingTalk, Retry: 1, RetryInterval: 1})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { _ = w.Close() }()
+
+	if err = w.Notify(&Alert{Level: ErrorLevel, Time: time.Now(), Message: "disk full", Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := atomic.LoadInt32(&s.requests); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+
+	if !strings.Contains(string(s.bodies[1]), `"msgtype":"text"`) {
+		t.Errorf("unexpected payload %s", s.bodies[1])
+	}
+}
+
+type blockingNotifier struct {
+	release  chan bool
+	notified int32
+}
+
+func (n *blockingNotifier) Notify(*Alert) error {
+	<-n.release
+	atomic.AddInt32(&n.notified, 1)
+	return nil
+}
+
+func TestHookDropsWhenFull(t *testing.T) {
+	l := newWebhookLogger(t)
+
+	n := &blockingNotifier{release: make(chan bool)}
+	l.AddHook(ErrorLevel, n)
+
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < hookQueueSize*2; i++ {
+			l.Error("queue full")
+		}
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging blocked on a slow notifier")
+	}
+
+	h := l.hooks.hooks[0]
+
+	if dropped := atomic.LoadInt64(&h.dropped); dropped < hookQueueSize-1 {
+		t.Errorf("dropped %d lines, want at least %d", dropped, hookQueueSize-1)
+	}
+
+	close(n.release)
+	_ = l.Close()
+
+	if notified := atomic.LoadInt32(&n.notified) + int32(atomic.LoadInt64(&h.dropped)); notified != hookQueueSize*2 {
+		t.Errorf("notified and dropped %d lines, want %d", notified, hookQueueSize*2)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/marsmay/golib/logger"
 )
 
 type ConnectConfig struct {
@@ -16,6 +17,7 @@ type ConnectConfig struct {
 	CleanSession      bool          `toml:"clean_session" json:"clean_session"`
 	ConnectTimeout    time.Duration `toml:"connect_timeout" json:"connect_timeout"`
 	DisconnectTimeout uint          `toml:"disconnect_timeout" json:"disconnect_timeout"`
+	LibraryLog        bool          `toml:"library_log" json:"library_log"`
 }
 
 func (c *ConnectConfig) GetAddr() string {
@@ -39,7 +41,19 @@ type IConfig interface {
 	DisconnectHandler(mqtt.Client, error)
 }
 
-func connect(c IConfig) (mqtt.Client, error) {
+// installLogger routes paho's global loggers to l, CRITICAL is logged as an error.
+func installLogger(l *logger.Logger) {
+	mqtt.ERROR = l.PrintLogger(logger.ErrorLevel)
+	mqtt.CRITICAL = l.PrintLogger(logger.ErrorLevel)
+	mqtt.WARN = l.PrintLogger(logger.WarnLevel)
+	mqtt.DEBUG = l.PrintLogger(logger.DebugLevel)
+}
+
+func connect(c IConfig, conf *ConnectConfig, l *logger.Logger) (mqtt.Client, error) {
+	if conf.LibraryLog {
+		installLogger(l)
+	}
+
 	options := c.GetOptions().
 		SetClientID(c.GetClientID()).
 		SetOnConnectHandler(c.ConnectHandler).
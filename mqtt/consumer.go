@@ -62,7 +62,7 @@ func (c *Consumer) DisconnectHandler(_ mqtt.Client, err error) {
 }
 
 func (c *Consumer) run() (err error) {
-	if c.client, err = connect(c); err != nil {
+	if c.client, err = connect(c, c.c.ConnectConfig, c.logger); err != nil {
 		return
 	}
 
@@ -100,6 +100,7 @@ func (c *Consumer) Stop() {
 
 func (c *Consumer) handle() {
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for {
 		select {
@@ -107,7 +108,7 @@ func (c *Consumer) handle() {
 			return
 		case msg := <-c.msgQueue:
 			if handler, ok := c.handlers[msg.Topic()]; ok {
-				handler(msg.Payload())
+				logger.Protect(c.logger, func() { handler(msg.Payload()) })
 			}
 		}
 	}
@@ -55,7 +55,7 @@ func (c *Producer) DisconnectHandler(_ mqtt.Client, err error) {
 }
 
 func (c *Producer) run() (err error) {
-	if c.client, err = connect(c); err != nil {
+	if c.client, err = connect(c, c.c.ConnectConfig, c.logger); err != nil {
 		return
 	}
 
@@ -88,6 +88,7 @@ func (c *Producer) retry(msg *Message) {
 
 func (c *Producer) publish() {
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for {
 		select {
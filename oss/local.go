@@ -0,0 +1,58 @@
+package oss
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Putter is the part of Client the uploader needs, LocalClient stands in for it in tests and dev setups.
+type Putter interface {
+	PutFromFile(objectKey, filePath string) error
+}
+
+// LocalClient stores objects as files under Dir, the object key being the relative path.
+type LocalClient struct {
+	Dir string
+}
+
+func (c *LocalClient) PutFromFile(objectKey, filePath string) (err error) {
+	dst := filepath.Join(c.Dir, filepath.FromSlash(objectKey))
+
+	if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return
+	}
+
+	src, err := os.Open(filePath)
+
+	if err != nil {
+		return
+	}
+
+	defer func() { _ = src.Close() }()
+
+	tmpFile := dst + ".tmp"
+	fd, err := os.Create(tmpFile)
+
+	if err != nil {
+		return
+	}
+
+	if _, err = io.Copy(fd, src); err == nil {
+		err = fd.Sync()
+	}
+
+	if e := fd.Close(); err == nil {
+		err = e
+	}
+
+	if err == nil {
+		err = os.Rename(tmpFile, dst)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpFile)
+	}
+
+	return
+}
@@ -0,0 +1,345 @@
+package oss
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marsmay/golib/logger"
+)
+
+const (
+	defaultKeyTemplate   = "{prefix}{date}/{file}"
+	defaultManifest      = ".upload_manifest.json"
+	defaultScanInterval  = 60000
+	defaultUploadDelay   = 120000
+	defaultRetryInterval = 1000
+)
+
+// partitionPattern matches a DataLogger file after its prefix: date, then hour or size segment, .log, .gz.
+var partitionPattern = regexp.MustCompile(`^(\d{8})(?:\.(\d+))?(?:\.(\d+))?\.log(\.gz)?$`)
+
+// UploaderConfig durations are in milliseconds. KeyTemplate accepts {prefix}, {date} (20060102),
+// {year}, {month}, {day}, {hour} and {file}, the base name of the local file. A partition is
+// uploaded Delay after it ended, so the writer and the compression of rotated files are done with it.
+type UploaderConfig struct {
+	KeyTemplate   string        `toml:"key_template" json:"key_template"`
+	Manifest      string        `toml:"manifest" json:"manifest"`
+	Interval      time.Duration `toml:"interval" json:"interval"`
+	Delay         time.Duration `toml:"delay" json:"delay"`
+	Retry         int           `toml:"retry" json:"retry"`
+	RetryInterval time.Duration `toml:"retry_interval" json:"retry_interval"`
+	Remove        bool          `toml:"remove" json:"remove"`
+}
+
+type ManifestEntry struct {
+	Key        string    `json:"key"`
+	Size       int64     `json:"size"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+type partitionFile struct {
+	path  string
+	name  string
+	start time.Time
+	end   time.Time
+}
+
+// Uploader ships the closed partition files of a DataLogger to OSS. The manifest, a JSON file in the data
+// directory by default, records every upload, so a restart neither uploads a file twice nor skips one.
+type Uploader struct {
+	c        *UploaderConfig
+	data     *logger.DataConfig
+	client   Putter
+	logger   *logger.Logger
+	manifest string
+	locker   sync.Mutex
+	uploaded map[string]*ManifestEntry
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       *sync.WaitGroup
+}
+
+func (u *Uploader) loadManifest() (err error) {
+	u.uploaded = make(map[string]*ManifestEntry)
+	data, err := ioutil.ReadFile(u.manifest)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+
+		return
+	}
+
+	return json.Unmarshal(data, &u.uploaded)
+}
+
+func (u *Uploader) saveManifest() (err error) {
+	data, err := json.MarshalIndent(u.uploaded, "", "  ")
+
+	if err != nil {
+		return
+	}
+
+	tmpFile := u.manifest + ".tmp"
+
+	if err = ioutil.WriteFile(tmpFile, data, 0644); err != nil {
+		return
+	}
+
+	return os.Rename(tmpFile, u.manifest)
+}
+
+// partitions lists the files of the data logger with the period they belong to, oldest first.
+func (u *Uploader) partitions() (files []*partitionFile, err error) {
+	infos, err := ioutil.ReadDir(u.data.Dir)
+
+	if err != nil {
+		return
+	}
+
+	for _, info := range infos {
+		name := info.Name()
+
+		if info.IsDir() || !strings.HasPrefix(name, u.data.Prefix) {
+			continue
+		}
+
+		m := partitionPattern.FindStringSubmatch(name[len(u.data.Prefix):])
+
+		if m == nil {
+			continue
+		}
+
+		start, e := time.ParseInLocation("20060102", m[1], u.data.Location)
+
+		if e != nil {
+			continue
+		}
+
+		end := start.AddDate(0, 0, 1)
+
+		// with hourly partitions the hour always follows the date, a size segment comes after it
+		if u.data.Partition == logger.PartitionHour {
+			if len(m[2]) != 2 {
+				continue
+			}
+
+			var hour int
+			_, _ = fmt.Sscanf(m[2], "%d", &hour)
+
+			// the file is named by wall-clock hour, which isn't hours since midnight on DST-change days;
+			// on the fall-back day the repeated hour shares one file, ended by the following wall hour
+			year, month, day := start.Date()
+			start = time.Date(year, month, day, hour, 0, 0, 0, u.data.Location)
+			end = time.Date(year, month, day, hour+1, 0, 0, 0, u.data.Location)
+		} else if m[3] != "" {
+			continue
+		}
+
+		// compressed partitions are renamed once gzipped, so only the final name is uploaded
+		if u.data.Compress && m[4] == "" {
+			continue
+		}
+
+		files = append(files, &partitionFile{path: filepath.Join(u.data.Dir, name), name: name, start: start, end: end})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].start.Before(files[j].start) || (files[i].start.Equal(files[j].start) && files[i].name < files[j].name)
+	})
+
+	return
+}
+
+func (u *Uploader) objectKey(f *partitionFile) string {
+	t := f.start
+
+	return strings.NewReplacer(
+		"{prefix}", u.data.Prefix,
+		"{date}", t.Format("20060102"),
+		"{year}", t.Format("2006"),
+		"{month}", t.Format("01"),
+		"{day}", t.Format("02"),
+		"{hour}", t.Format("15"),
+		"{file}", f.name,
+	).Replace(u.c.KeyTemplate)
+}
+
+func (u *Uploader) put(key, file string) (err error) {
+	interval := u.c.RetryInterval * time.Millisecond
+
+	for i := 0; ; i++ {
+		if err = u.client.PutFromFile(key, file); err == nil || i >= u.c.Retry {
+			return
+		}
+
+		select {
+		case <-u.ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+	}
+}
+
+// Scan uploads every closed partition not in the manifest yet, a failed file is tried again on the next scan.
+func (u *Uploader) Scan() (err error) {
+	u.locker.Lock()
+	defer u.locker.Unlock()
+
+	files, err := u.partitions()
+
+	if err != nil {
+		return
+	}
+
+	deadline := time.Now().Add(-u.c.Delay * time.Millisecond)
+	present := make(map[string]bool, len(files))
+
+	for _, f := range files {
+		present[f.name] = true
+
+		if _, ok := u.uploaded[f.name]; ok || f.end.After(deadline) {
+			continue
+		}
+
+		info, e := os.Stat(f.path)
+
+		if e != nil {
+			continue
+		}
+
+		key := u.objectKey(f)
+
+		if e = u.put(key, f.path); e != nil {
+			u.logger.Errorf("upload data file failed | file: %s | key: %s | error: %s", f.path, key, e)
+			err = e
+			continue
+		}
+
+		u.uploaded[f.name] = &ManifestEntry{Key: key, Size: info.Size(), UploadedAt: time.Now()}
+
+		if e = u.saveManifest(); e != nil {
+			u.logger.Errorf("save upload manifest failed | manifest: %s | error: %s", u.manifest, e)
+			err = e
+		}
+
+		u.logger.Infof("upload data file | file: %s | key: %s | size: %d", f.path, key, info.Size())
+
+		if u.c.Remove {
+			if e = os.Remove(f.path); e != nil {
+				u.logger.Warningf("remove uploaded data file failed | file: %s | error: %s", f.path, e)
+			}
+		}
+	}
+
+	// forget files that are gone, removed after upload or by retention
+	changed := false
+
+	for name := range u.uploaded {
+		if !present[name] {
+			delete(u.uploaded, name)
+			changed = true
+		}
+	}
+
+	if changed {
+		if e := u.saveManifest(); e != nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// Uploaded returns a copy of the manifest, keyed by local file name.
+func (u *Uploader) Uploaded() map[string]ManifestEntry {
+	u.locker.Lock()
+	defer u.locker.Unlock()
+
+	entries := make(map[string]ManifestEntry, len(u.uploaded))
+
+	for name, entry := range u.uploaded {
+		entries[name] = *entry
+	}
+
+	return entries
+}
+
+func (u *Uploader) run() {
+	defer u.wg.Done()
+	defer logger.RecoverAndLog(u.logger, true)
+
+	ticker := time.NewTicker(u.c.Interval * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		_ = u.Scan()
+
+		select {
+		case <-u.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *Uploader) Stop() {
+	u.cancel()
+	u.wg.Wait()
+}
+
+// NewUploader starts scanning the directory of data at once and then every Interval.
+func NewUploader(c *UploaderConfig, data *logger.DataConfig, client Putter, l *logger.Logger) (uploader *Uploader, err error) {
+	if client == nil {
+		return nil, errors.New("no oss client")
+	}
+
+	if c.KeyTemplate == "" {
+		c.KeyTemplate = defaultKeyTemplate
+	}
+
+	if c.Interval <= 0 {
+		c.Interval = defaultScanInterval
+	}
+
+	if c.Delay <= 0 {
+		c.Delay = defaultUploadDelay
+	}
+
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = defaultRetryInterval
+	}
+
+	if data.Location == nil {
+		data.LoadLoc()
+	}
+
+	uploader = &Uploader{c: c, data: data, client: client, logger: l, manifest: c.Manifest, wg: &sync.WaitGroup{}}
+
+	if uploader.manifest == "" {
+		uploader.manifest = filepath.Join(data.Dir, defaultManifest)
+	}
+
+	if err = uploader.loadManifest(); err != nil {
+		return nil, err
+	}
+
+	uploader.ctx, uploader.cancel = context.WithCancel(context.Background())
+
+	uploader.wg.Add(1)
+	go uploader.run()
+	return
+}
@@ -0,0 +1,96 @@
+package prometheus
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/marsmay/golib/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type cacheCollector struct {
+	locker      sync.RWMutex
+	sources     map[string]func() cache.Stats
+	hits        *prometheus.Desc
+	misses      *prometheus.Desc
+	evictions   *prometheus.Desc
+	expirations *prometheus.Desc
+	entries     *prometheus.Desc
+	cost        *prometheus.Desc
+}
+
+func newCacheCollector(constLabels map[string]string) *cacheCollector {
+	labels := []string{"cache"}
+
+	return &cacheCollector{
+		sources:     make(map[string]func() cache.Stats, 4),
+		hits:        prometheus.NewDesc("cache_hits_total", "Number of cache hits.", labels, constLabels),
+		misses:      prometheus.NewDesc("cache_misses_total", "Number of cache misses.", labels, constLabels),
+		evictions:   prometheus.NewDesc("cache_evictions_total", "Number of entries evicted for capacity.", labels, constLabels),
+		expirations: prometheus.NewDesc("cache_expirations_total", "Number of entries removed after expiry.", labels, constLabels),
+		entries:     prometheus.NewDesc("cache_entries", "Number of entries currently stored.", labels, constLabels),
+		cost:        prometheus.NewDesc("cache_cost", "Total cost of entries currently stored.", labels, constLabels),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.expirations
+	ch <- c.entries
+	ch <- c.cost
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	c.locker.RLock()
+	names := make([]string, 0, len(c.sources))
+
+	for name := range c.sources {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	sources := make([]func() cache.Stats, len(names))
+
+	for i, name := range names {
+		sources[i] = c.sources[name]
+	}
+
+	c.locker.RUnlock()
+
+	for i, name := range names {
+		stats := sources[i]()
+
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits), name)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses), name)
+		ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions), name)
+		ch <- prometheus.MustNewConstMetric(c.expirations, prometheus.CounterValue, float64(stats.Expirations), name)
+		ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Size), name)
+		ch <- prometheus.MustNewConstMetric(c.cost, prometheus.GaugeValue, float64(stats.Cost), name)
+	}
+}
+
+// RegisterCache exports the statistics of a cache.Cache, labelled by name, e.g. m.RegisterCache("users", c.Stats).
+// If the collector couldn't be registered, every call returns that error.
+func (m *Monitor) RegisterCache(name string, stats func() cache.Stats) error {
+	m.cacheOnce.Do(func() {
+		m.caches = newCacheCollector(m.config.ConstLabels)
+		m.cacheErr = prometheus.Register(m.caches)
+	})
+
+	if m.cacheErr != nil {
+		return m.cacheErr
+	}
+
+	m.caches.locker.Lock()
+	defer m.caches.locker.Unlock()
+
+	if _, ok := m.caches.sources[name]; ok {
+		return fmt.Errorf("cache '%s' already registered", name)
+	}
+
+	m.caches.sources[name] = stats
+	return nil
+}
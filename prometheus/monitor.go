@@ -5,9 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net"
-	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -49,12 +49,6 @@ type Vector struct {
 	logger *logger.Logger
 }
 
-func (v *Vector) catchPanic() {
-	if err := recover(); err != nil {
-		v.logger.Fatalf("catch panic | %s\n%s", err, debug.Stack())
-	}
-}
-
 func (v *Vector) Trigger(value float64, labels ...string) {
 	if len(labels) != len(v.config.Labels) {
 		v.logger.Errorf("invalid vector labels | name: %s | labels: %+v", v.config.Name, labels)
@@ -104,7 +98,7 @@ func getClietIP(ctx context.Context) (ip string, err error) {
 
 // NOTE: vector type must is Histogram or Summary
 func (v *Vector) GrpcServerUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-	defer v.catchPanic()
+	defer logger.RecoverAndLog(v.logger, false)
 
 	start := time.Now()
 	clientIp := "unknown"
@@ -123,7 +117,7 @@ func (v *Vector) GrpcServerUnaryInterceptor(ctx context.Context, req interface{}
 
 // NOTE: vector type must is Histogram or Summary
 func (v *Vector) GrpcServerStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
-	defer v.catchPanic()
+	defer logger.RecoverAndLog(v.logger, false)
 
 	start := time.Now()
 	clientIp := "unknown"
@@ -142,7 +136,7 @@ func (v *Vector) GrpcServerStreamInterceptor(srv interface{}, stream grpc.Server
 
 // NOTE: vector type must is Histogram or Summary
 func (v *Vector) GrpcClientUnaryInterceptor(ctx context.Context, method string, req, resp interface{}, conn *grpc.ClientConn, invoker grpc.UnaryInvoker, options ...grpc.CallOption) (err error) {
-	defer v.catchPanic()
+	defer logger.RecoverAndLog(v.logger, false)
 
 	start := time.Now()
 	err = invoker(ctx, method, req, resp, conn, options...)
@@ -155,7 +149,7 @@ func (v *Vector) GrpcClientUnaryInterceptor(ctx context.Context, method string,
 
 // NOTE: vector type must is Histogram or Summary
 func (v *Vector) GrpcClientStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, conn *grpc.ClientConn, method string, streamer grpc.Streamer, options ...grpc.CallOption) (stream grpc.ClientStream, err error) {
-	defer v.catchPanic()
+	defer logger.RecoverAndLog(v.logger, false)
 
 	start := time.Now()
 	stream, err = streamer(ctx, desc, conn, method, options...)
@@ -171,9 +165,12 @@ func (v *Vector) Config() *VectorConfig {
 }
 
 type Monitor struct {
-	config  *Config
-	vectors map[string]*Vector
-	logger  *logger.Logger
+	config    *Config
+	vectors   map[string]*Vector
+	logger    *logger.Logger
+	caches    *cacheCollector
+	cacheOnce sync.Once
+	cacheErr  error
 }
 
 func (m *Monitor) Register(config *VectorConfig) (err error) {
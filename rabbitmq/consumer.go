@@ -72,16 +72,22 @@ func (c *Consumer) Stop() {
 
 func (c *Consumer) handle() {
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for msg := range c.queue {
 		c.logger.Debugf("receive rabbitmq message | msg: %s", msg.Body)
 
-		if err := c.handler(msg); err == nil {
+		var err error
+
+		panicked := logger.Protect(c.logger, func() { err = c.handler(msg) })
+
+		if err == nil && !panicked {
 			if err := msg.Ack(false); err != nil {
 				c.logger.Errorf("ack rabbitmq message failed | message: %+v | error: %s", msg, err)
 			}
 		} else {
-			if err := msg.Reject(true); err != nil {
+			// a message that panics the handler would panic it again, so only failed ones are requeued
+			if err := msg.Reject(!panicked); err != nil {
 				c.logger.Errorf("reject rabbitmq message failed | message: %+v | error: %s", msg, err)
 			}
 		}
@@ -136,6 +142,7 @@ func (c *Consumer) init() (err error) {
 
 func (c *Consumer) checkConn() {
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for {
 		select {
@@ -85,6 +85,7 @@ func (c *Producer) Send(msg *amqp.Publishing) error {
 
 func (c *Producer) send() {
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for msg := range c.queue {
 		if err := c.channel.Publish(c.conf.Exchange, c.conf.RoutingKey, false, false, *msg); err != nil {
@@ -129,6 +130,7 @@ func (c *Producer) init() (err error) {
 
 func (c *Producer) checkConn() {
 	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
 
 	for {
 		select {
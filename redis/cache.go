@@ -0,0 +1,243 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/google/uuid"
+	"github.com/marsmay/golib/cache"
+	"github.com/marsmay/golib/coder"
+	"github.com/marsmay/golib/logger"
+)
+
+// defaultLocalTtl bounds the staleness of L1 entries when LocalTtl isn't set, in milliseconds.
+const defaultLocalTtl = 60000
+
+type CacheConfig struct {
+	Prefix   string        `toml:"prefix" json:"prefix"`
+	Channel  string        `toml:"channel" json:"channel"`
+	Ttl      time.Duration `toml:"ttl" json:"ttl"`
+	LocalTtl time.Duration `toml:"local_ttl" json:"local_ttl"`
+	Local    *cache.Config `toml:"local" json:"local"`
+}
+
+type invalidation struct {
+	Source string   `json:"source"`
+	Keys   []string `json:"keys"`
+}
+
+// Cache keeps a local cache.Cache (L1) in front of redis (L2). Every Set or Delete is published on
+// Channel, and the other instances drop the key from their L1. Messages published while an instance
+// is disconnected are lost, so LocalTtl bounds how long it can serve a stale value; it defaults to
+// one minute, L1 entries never live forever.
+type Cache[V any] struct {
+	conf   *CacheConfig
+	id     string
+	local  *cache.Cache[string, V]
+	client *redis.Client
+	coder  coder.ICoder
+	pubsub *redis.PubSub
+	logger *logger.Logger
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     *sync.WaitGroup
+}
+
+func (c *Cache[V]) localTtl() time.Duration {
+	if c.conf.LocalTtl <= 0 {
+		return defaultLocalTtl * time.Millisecond
+	}
+
+	return c.conf.LocalTtl * time.Millisecond
+}
+
+func (c *Cache[V]) key(key string) string {
+	return c.conf.Prefix + key
+}
+
+func (c *Cache[V]) encode(value V) ([]byte, error) {
+	return c.coder.Marshal(value)
+}
+
+func (c *Cache[V]) decode(data []byte) (value V, err error) {
+	// pointer values (e.g. protobuf messages) are decoded into a new instance instead of into the pointer itself
+	if t := reflect.TypeOf(value); t != nil && t.Kind() == reflect.Ptr {
+		value = reflect.New(t.Elem()).Interface().(V)
+		err = c.coder.Unmarshal(data, value)
+		return
+	}
+
+	err = c.coder.Unmarshal(data, &value)
+	return
+}
+
+func (c *Cache[V]) loadRemote(key string) (value V, err error) {
+	data, err := c.client.Get(c.key(key)).Bytes()
+
+	if err == redis.Nil {
+		err = cache.ErrNotFound
+	}
+
+	if err != nil {
+		return
+	}
+
+	return c.decode(data)
+}
+
+// Get reads L1, then redis; it returns cache.ErrNotFound when neither has the key.
+func (c *Cache[V]) Get(key string) (value V, err error) {
+	return c.local.GetOrLoad(key, c.localTtl(), c.loadRemote)
+}
+
+// GetOrLoad reads L1, then redis, then calls loader and stores its result in both levels.
+func (c *Cache[V]) GetOrLoad(key string, loader cache.Loader[string, V]) (value V, err error) {
+	return c.local.GetOrLoad(key, c.localTtl(), func(key string) (value V, err error) {
+		if value, err = c.loadRemote(key); err != cache.ErrNotFound {
+			return
+		}
+
+		if value, err = loader(key); err != nil {
+			return
+		}
+
+		data, e := c.encode(value)
+
+		if e != nil {
+			c.logger.Warningf("encode cache value failed | key: %s | error: %s", key, e)
+			return
+		}
+
+		if e = c.client.Set(c.key(key), data, c.conf.Ttl*time.Millisecond).Err(); e != nil {
+			c.logger.Warningf("set redis cache failed | key: %s | error: %s", key, e)
+		}
+
+		return
+	})
+}
+
+func (c *Cache[V]) Set(key string, value V) (err error) {
+	data, err := c.encode(value)
+
+	if err != nil {
+		return
+	}
+
+	if err = c.client.Set(c.key(key), data, c.conf.Ttl*time.Millisecond).Err(); err != nil {
+		return
+	}
+
+	c.local.Set(key, value, c.localTtl())
+	return c.publish(key)
+}
+
+func (c *Cache[V]) Delete(keys ...string) (err error) {
+	if len(keys) == 0 {
+		return
+	}
+
+	remoteKeys := make([]string, len(keys))
+
+	for i, key := range keys {
+		remoteKeys[i] = c.key(key)
+	}
+
+	if err = c.client.Del(remoteKeys...).Err(); err != nil {
+		return
+	}
+
+	for _, key := range keys {
+		c.local.Delete(key)
+	}
+
+	return c.publish(keys...)
+}
+
+func (c *Cache[V]) publish(keys ...string) (err error) {
+	msg, err := json.Marshal(&invalidation{Source: c.id, Keys: keys})
+
+	if err != nil {
+		return
+	}
+
+	return c.client.Publish(c.conf.Channel, msg).Err()
+}
+
+func (c *Cache[V]) subscribe() {
+	defer c.wg.Done()
+	defer logger.RecoverAndLog(c.logger, true)
+
+	ch := c.pubsub.Channel()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			inv := &invalidation{}
+
+			if err := json.Unmarshal([]byte(msg.Payload), inv); err != nil {
+				c.logger.Warningf("decode cache invalidation failed | channel: %s | payload: %s | error: %s", msg.Channel, msg.Payload, err)
+				continue
+			}
+
+			if inv.Source == c.id {
+				continue
+			}
+
+			for _, key := range inv.Keys {
+				c.local.Delete(key)
+			}
+		}
+	}
+}
+
+func (c *Cache[V]) Local() *cache.Cache[string, V] {
+	return c.local
+}
+
+func (c *Cache[V]) Close() {
+	c.cancel()
+
+	if err := c.pubsub.Close(); err != nil {
+		c.logger.Warningf("close cache subscription failed | channel: %s | error: %s", c.conf.Channel, err)
+	}
+
+	c.wg.Wait()
+	_ = c.local.Close()
+}
+
+func NewCache[V any](conf *CacheConfig, client *redis.Client, coder coder.ICoder, logger *logger.Logger) (c *Cache[V], err error) {
+	c = &Cache[V]{
+		conf:   conf,
+		id:     uuid.New().String(),
+		local:  cache.New[string, V](conf.Local),
+		client: client,
+		coder:  coder,
+		logger: logger,
+		wg:     &sync.WaitGroup{},
+	}
+
+	c.pubsub = client.Subscribe(conf.Channel)
+
+	if _, err = c.pubsub.Receive(); err != nil {
+		_ = c.pubsub.Close()
+		_ = c.local.Close()
+		return
+	}
+
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	c.wg.Add(1)
+	go c.subscribe()
+
+	return
+}
@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/marsmay/golib/logger"
+	"github.com/marsmay/golib/zookeeper"
+)
+
+const (
+	defaultCoordinatorRoot = "/scheduler"
+	defaultKeepHours       = 24
+	doneSuffix             = ".done"
+	watchMinBackoff        = time.Second
+	watchMaxBackoff        = 30 * time.Second
+)
+
+// CoordinatorConfig places the claims under Root/<provider>/<firing>; markers of finished
+// firings are kept for KeepHours so a late instance doesn't run them again.
+type CoordinatorConfig struct {
+	Root      string `toml:"root" json:"root"`
+	Instance  string `toml:"instance" json:"instance"`
+	KeepHours int    `toml:"keep_hours" json:"keep_hours"`
+}
+
+// Coordinator makes each scheduled firing run on exactly one of the instances sharing a ZooKeeper root.
+// The instance that creates the ephemeral claim node of a firing runs it and leaves a done marker,
+// the others skip it and watch the claim: if it vanishes without a marker, the holder's session
+// expired mid-run and the first instance to claim it again takes the firing over.
+type Coordinator struct {
+	c      *CoordinatorConfig
+	client *zookeeper.Client
+	logger *logger.Logger
+}
+
+// claimPath keys a firing by its UTC time, so instances in different time zones agree on it
+// and the two firings of a repeated fall-back hour don't share a key.
+func (c *Coordinator) claimPath(provider string, t time.Time) string {
+	return path.Join(c.c.Root, provider, t.UTC().Format(SignalFormat))
+}
+
+func (c *Coordinator) finished(claim string) (bool, error) {
+	ok, _, err := c.client.Conn().Exists(claim + doneSuffix)
+	return ok, err
+}
+
+// claim reports whether this instance holds the firing now.
+func (c *Coordinator) claim(provider string, t time.Time) (ok bool, err error) {
+	claim := c.claimPath(provider, t)
+
+	if ok, err = c.finished(claim); err != nil || ok {
+		return false, err
+	}
+
+	err = c.client.Create(claim, []byte(c.c.Instance), zk.FlagEphemeral, zookeeper.PermWorldAll)
+
+	if err == zk.ErrNodeExists {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	// the holder may have marked the firing done and released it between the check and the create
+	if ok, err = c.finished(claim); err != nil || ok {
+		_ = c.client.Delete(claim)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// done marks the firing as run, releases the claim and drops the markers older than KeepHours.
+func (c *Coordinator) done(provider string, t time.Time) {
+	claim := c.claimPath(provider, t)
+	data := []byte(c.c.Instance + " " + time.Now().Format(time.RFC3339))
+
+	if err := c.client.Create(claim+doneSuffix, data, zookeeper.FlagPersistent, zookeeper.PermWorldAll); err != nil && err != zk.ErrNodeExists {
+		c.logger.Errorf("[%s] mark firing done failed | firing: %s | error: %s", provider, t.Format(SignalFormat), err)
+	}
+
+	if err := c.client.Delete(claim); err != nil && err != zk.ErrNoNode {
+		c.logger.Warningf("[%s] release firing failed | firing: %s | error: %s", provider, t.Format(SignalFormat), err)
+	}
+
+	c.prune(provider, t)
+}
+
+func (c *Coordinator) prune(provider string, now time.Time) {
+	dir := path.Join(c.c.Root, provider)
+	nodes, err := c.client.Children(dir)
+
+	if err != nil {
+		return
+	}
+
+	deadline := now.Add(-time.Duration(c.c.KeepHours) * time.Hour)
+
+	for _, node := range nodes {
+		if !strings.HasSuffix(node, doneSuffix) {
+			continue
+		}
+
+		t, e := time.Parse(SignalFormat, strings.TrimSuffix(node, doneSuffix))
+
+		if e == nil && t.Before(deadline) {
+			_ = c.client.Delete(path.Join(dir, node))
+		}
+	}
+}
+
+// watch follows a firing claimed by another instance and calls takeover once this instance
+// claimed it after the holder vanished without finishing it. ZooKeeper errors, e.g. while the
+// session reconnects, are retried with backoff until the firing is finished or ctx is done.
+func (c *Coordinator) watch(ctx context.Context, provider string, t time.Time, takeover func()) {
+	claim := c.claimPath(provider, t)
+
+	go func() {
+		defer logger.RecoverAndLog(c.logger, true)
+
+		var backoff time.Duration
+
+		for {
+			exists, _, eventCh, err := c.client.Conn().ExistsW(claim)
+
+			if err == nil && !exists {
+				var ok bool
+
+				if ok, err = c.claim(provider, t); ok {
+					c.logger.Warningf("[%s] take over firing of an expired instance | firing: %s", provider, t.Format(SignalFormat))
+					takeover()
+					return
+				}
+
+				if err == nil {
+					// finished, or claimed by a quicker instance that is watched from now on
+					var done bool
+
+					if done, err = c.finished(claim); err == nil && done {
+						return
+					}
+				}
+
+				if err == nil {
+					backoff = 0
+					continue
+				}
+			}
+
+			if err != nil {
+				if backoff *= 2; backoff < watchMinBackoff {
+					backoff = watchMinBackoff
+				} else if backoff > watchMaxBackoff {
+					backoff = watchMaxBackoff
+				}
+
+				c.logger.Warningf("[%s] watch firing failed, retry in %s | firing: %s | error: %s", provider, backoff, t.Format(SignalFormat), err)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff):
+				}
+
+				continue
+			}
+
+			backoff = 0
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-eventCh:
+			}
+		}
+	}()
+}
+
+func NewCoordinator(c *CoordinatorConfig, client *zookeeper.Client, l *logger.Logger) *Coordinator {
+	if c.Root == "" {
+		c.Root = defaultCoordinatorRoot
+	}
+
+	if c.Instance == "" {
+		host, _ := os.Hostname()
+		c.Instance = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+
+	if c.KeepHours <= 0 {
+		c.KeepHours = defaultKeepHours
+	}
+
+	return &Coordinator{c: c, client: client, logger: l}
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// HistorySize is the number of recent executions kept for each provider.
+const HistorySize = 100
+
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+	ResultTimeout = "timeout"
+	ResultPanic   = "panic"
+)
+
+// Execution is one run of a firing, retries included. Running is set while the last attempt goes on
+// after its timeout, the job ignored its context.
+type Execution struct {
+	Firing   time.Time     `json:"firing"`
+	Signal   bool          `json:"signal"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Attempts int           `json:"attempts"`
+	Result   string        `json:"result"`
+	Error    string        `json:"error,omitempty"`
+	Running  bool          `json:"running"`
+	seq      int64
+}
+
+func result(err error) string {
+	var pe *PanicError
+
+	switch {
+	case err == nil:
+		return ResultSuccess
+	case errors.Is(err, ErrTimeout):
+		return ResultTimeout
+	case errors.As(err, &pe):
+		return ResultPanic
+	default:
+		return ResultFailed
+	}
+}
+
+// history is a ring of the last executions of a worker.
+type history struct {
+	locker  sync.Mutex
+	records []Execution
+	next    int
+	full    bool
+	seq     int64
+}
+
+func newHistory(size int) *history {
+	return &history{records: make([]Execution, size)}
+}
+
+// add records e and returns its sequence number for finish.
+func (h *history) add(e Execution) int64 {
+	h.locker.Lock()
+	defer h.locker.Unlock()
+
+	h.seq++
+	e.seq = h.seq
+	h.records[h.next] = e
+
+	if h.next++; h.next == len(h.records) {
+		h.next, h.full = 0, true
+	}
+
+	return e.seq
+}
+
+// finish clears Running once a timed out run returns, unless the ring has moved past it.
+func (h *history) finish(seq int64) {
+	h.locker.Lock()
+	defer h.locker.Unlock()
+
+	for i := range h.records {
+		if h.records[i].seq == seq {
+			h.records[i].Running = false
+			return
+		}
+	}
+}
+
+// list returns the executions oldest first.
+func (h *history) list() []Execution {
+	h.locker.Lock()
+	defer h.locker.Unlock()
+
+	if !h.full {
+		return append([]Execution(nil), h.records[:h.next]...)
+	}
+
+	records := make([]Execution, 0, len(h.records))
+	return append(append(records, h.records[h.next:]...), h.records[:h.next]...)
+}
@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"testing"
+)
+
+func TestHistoryWraps(t *testing.T) {
+	h := newHistory(HistorySize)
+	seqs := make([]int64, 0, HistorySize+5)
+
+	for i := 1; i <= HistorySize+5; i++ {
+		seqs = append(seqs, h.add(Execution{Attempts: i, Running: true}))
+	}
+
+	records := h.list()
+
+	if len(records) != HistorySize {
+		t.Fatalf("kept %d executions, want %d", len(records), HistorySize)
+	}
+
+	for i, e := range records {
+		if want := i + 6; e.Attempts != want {
+			t.Fatalf("execution %d is #%d, want #%d", i, e.Attempts, want)
+		}
+	}
+
+	// the first executions were overwritten, finishing them must not touch the ones kept
+	h.finish(seqs[0])
+	h.finish(seqs[len(seqs)-1])
+
+	records = h.list()
+
+	if !records[0].Running || records[len(records)-1].Running {
+		t.Errorf("finish cleared the wrong execution: first %+v, last %+v", records[0], records[len(records)-1])
+	}
+}
@@ -7,125 +7,240 @@ import (
 	"time"
 )
 
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+var weekdayNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+// Interval is a cron rule: "minute hour day month weekday", optionally led by a seconds field,
+// a macro such as @daily, or "@every 5m". Day and weekday combine like POSIX cron: when both are
+// restricted a time matches if either does.
 type Interval struct {
-	minutes  map[int]bool
-	hours    map[int]bool
-	days     map[int]bool
-	months   map[int]bool
-	weekdays map[int]bool
+	seconds    map[int]bool
+	minutes    map[int]bool
+	hours      map[int]bool
+	days       map[int]bool
+	months     map[int]bool
+	weekdays   map[int]bool
+	anyDay     bool
+	anyWeekday bool
+	every      time.Duration
 }
 
-func parseRule(rule string, minCap, maxCap int) (err error, set map[int]bool) {
-	items := strings.Split(rule, ",")
-	set = make(map[int]bool, len(items))
+func parseValue(s string, param *IValue) (int, error) {
+	if v, ok := param.Names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
 
-	for _, item := range items {
-		item = strings.TrimSpace(item)
+	v, err := strconv.Atoi(s)
 
-		if item == "" {
-			err = fmt.Errorf("error interval format: '%s'", rule)
-			return
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s'", s)
+	}
+
+	if v < param.MinCap || v > param.MaxCap {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, param.MinCap, param.MaxCap)
+	}
+
+	return v, nil
+}
+
+// parseItem adds one comma separated item: *, n, a-b, optionally followed by /step; a/step runs to the maximum.
+func parseItem(item string, param *IValue, set map[int]bool) (err error) {
+	rangePart, step := item, 1
+
+	if i := strings.Index(item, "/"); i >= 0 {
+		rangePart = item[:i]
+
+		if step, err = strconv.Atoi(item[i+1:]); err != nil || step <= 0 {
+			return fmt.Errorf("invalid step '%s'", item[i+1:])
 		}
+	}
 
-		if item == "*" {
-			for n := minCap; n <= maxCap; n++ {
-				set[n] = true
-			}
+	start, end := param.MinCap, param.MaxCap
 
+	switch {
+	case rangePart == "*" || rangePart == "?":
+	case strings.Contains(rangePart, "-"):
+		bounds := strings.SplitN(rangePart, "-", 2)
+
+		if start, err = parseValue(bounds[0], param); err != nil {
 			return
 		}
 
-		elements := strings.Split(item, "/")
+		if end, err = parseValue(bounds[1], param); err != nil {
+			return
+		}
 
-		if len(elements) == 2 && elements[0] == "*" {
-			v, e := strconv.Atoi(elements[1])
+		if start > end {
+			return fmt.Errorf("invalid range '%s'", rangePart)
+		}
+	default:
+		if start, err = parseValue(rangePart, param); err != nil {
+			return
+		}
 
-			if e != nil {
-				err = fmt.Errorf("error interval format: '%s'", rule)
-				return
-			}
+		if step == 1 && !strings.Contains(item, "/") {
+			end = start
+		}
+	}
 
-			for n := minCap; n <= maxCap; n++ {
-				if n%v == 0 {
-					set[n] = true
-				}
-			}
+	for n := start; n <= end; n += step {
+		set[n] = true
+	}
 
-			continue
-		}
+	return
+}
 
-		if len(elements) == 1 {
-			v, e := strconv.Atoi(elements[0])
+func parseRule(rule string, param *IValue) (set map[int]bool, err error) {
+	items := strings.Split(rule, ",")
+	set = make(map[int]bool, param.MaxCap-param.MinCap+1)
 
-			if e != nil || v < minCap && v > maxCap {
-				err = fmt.Errorf("error interval format: '%s'", rule)
-				return
-			}
+	for _, item := range items {
+		item = strings.TrimSpace(item)
 
-			set[v] = true
-			continue
+		if item == "" {
+			err = fmt.Errorf("error interval format: %s field '%s': empty item", param.Name, rule)
+			return
 		}
 
-		err = fmt.Errorf("error interval format: '%s'", rule)
-		return
+		if e := parseItem(item, param, set); e != nil {
+			err = fmt.Errorf("error interval format: %s field '%s': %s", param.Name, rule, e)
+			return
+		}
 	}
 
 	return
 }
 
+func (o *Interval) checkDay(t time.Time) bool {
+	anyDay, anyWeekday := o.anyDay || len(o.days) == 0, o.anyWeekday || len(o.weekdays) == 0
+	day, weekday := o.days[t.Day()], o.weekdays[int(t.Weekday())]
+
+	switch {
+	case anyDay && anyWeekday:
+		return true
+	case anyDay:
+		return weekday
+	case anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
 func (o *Interval) Check(t time.Time) bool {
-	if len(o.minutes) != 0 && !o.minutes[t.Minute()] {
-		return false
+	if o.every > 0 {
+		// ticker times and time.Now carry nanoseconds, the firing is the whole second
+		return t.Truncate(time.Second).Unix()%int64(o.every/time.Second) == 0
 	}
 
-	if len(o.hours) != 0 && !o.hours[t.Hour()] {
+	if len(o.seconds) != 0 && !o.seconds[t.Second()] {
 		return false
 	}
 
-	if len(o.days) != 0 && !o.days[t.Day()] {
+	if len(o.minutes) != 0 && !o.minutes[t.Minute()] {
 		return false
 	}
 
-	if len(o.months) != 0 && !o.months[int(t.Month())] {
+	if len(o.hours) != 0 && !o.hours[t.Hour()] {
 		return false
 	}
 
-	if len(o.weekdays) != 0 && !o.weekdays[int(t.Weekday())] {
+	if len(o.months) != 0 && !o.months[int(t.Month())] {
 		return false
 	}
 
-	return true
+	return o.checkDay(t)
 }
 
 type IValue struct {
 	Ref    *map[int]bool
 	MinCap int
 	MaxCap int
+	Name   string
+	Names  map[string]int
+}
+
+func newEvery(rules string) (interval *Interval, err error) {
+	d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(rules, "@every")))
+
+	if err != nil || d < time.Second || d%time.Second != 0 {
+		return nil, fmt.Errorf("error interval format: '%s': @every needs a whole number of seconds", rules)
+	}
+
+	return &Interval{every: d}, nil
 }
 
 func NewInterval(rules string) (interval *Interval, err error) {
-	items := strings.Split(strings.TrimSpace(rules), " ")
+	rules = strings.TrimSpace(rules)
+
+	if strings.HasPrefix(rules, "@every") {
+		return newEvery(rules)
+	}
+
+	if strings.HasPrefix(rules, "@") {
+		macro, ok := macros[strings.ToLower(rules)]
+
+		if !ok {
+			err = fmt.Errorf("error interval format: unknown macro '%s'", rules)
+			return
+		}
+
+		rules = macro
+	}
+
+	items := strings.Fields(rules)
 
-	if len(items) != 5 {
-		err = fmt.Errorf("error interval format: '%s'", rules)
+	if len(items) != 5 && len(items) != 6 {
+		err = fmt.Errorf("error interval format: '%s': want 5 or 6 fields, got %d", rules, len(items))
 		return
 	}
 
 	interval = &Interval{}
 	params := []IValue{
-		{&interval.minutes, 0, 59},
-		{&interval.hours, 0, 23},
-		{&interval.days, 1, 31},
-		{&interval.months, 1, 12},
-		{&interval.weekdays, 0, 7},
+		{&interval.minutes, 0, 59, "minute", nil},
+		{&interval.hours, 0, 23, "hour", nil},
+		{&interval.days, 1, 31, "day", nil},
+		{&interval.months, 1, 12, "month", monthNames},
+		{&interval.weekdays, 0, 7, "weekday", weekdayNames},
 	}
 
-	for index, param := range params {
-		if err, *param.Ref = parseRule(items[index], param.MinCap, param.MaxCap); err != nil {
-			err = fmt.Errorf("error interval format: '%s'", rules)
-			return
+	if len(items) == 6 {
+		params = append([]IValue{{&interval.seconds, 0, 59, "second", nil}}, params...)
+	}
+
+	for index := range params {
+		if *params[index].Ref, err = parseRule(items[index], &params[index]); err != nil {
+			return nil, err
 		}
 	}
 
+	// 7 is another name for sunday
+	if interval.weekdays[7] {
+		delete(interval.weekdays, 7)
+		interval.weekdays[0] = true
+	}
+
+	interval.anyDay = isAny(items[len(items)-3])
+	interval.anyWeekday = isAny(items[len(items)-1])
 	return
 }
+
+func isAny(rule string) bool {
+	return strings.HasPrefix(rule, "*") || strings.HasPrefix(rule, "?")
+}
@@ -0,0 +1,205 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/marsmay/golib/logger"
+)
+
+// ErrTimeout is the error of a run that didn't return within the timeout of its job.
+var ErrTimeout = errors.New("run timed out")
+
+// IJob is the provider contract with a context and a result. The context is canceled on timeout
+// or when the master stops, a returned error or a panic fails the run and may retry it.
+type IJob interface {
+	Init() error
+	GetName() string
+	CheckInterval(time.Time) bool
+	Run(ctx context.Context, t time.Time) error
+	String() string
+}
+
+// RunPolicy bounds the runs of a job: each attempt gets Timeout, a failed one is retried Retry times,
+// waiting RetryInterval before the first retry and twice as long before each next one.
+type RunPolicy struct {
+	Timeout       time.Duration
+	Retry         int
+	RetryInterval time.Duration
+}
+
+// IRunPolicy is implemented by jobs with a run policy, such as Provider; others run once without timeout.
+type IRunPolicy interface {
+	RunPolicy() RunPolicy
+}
+
+// PanicError is the error of a run that panicked.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// providerJob adapts an IProvider to IJob, its runs ignore the context and only fail by panicking.
+type providerJob struct {
+	p IProvider
+}
+
+// AdaptProvider turns a provider with the original Run(time.Time) into a job.
+func AdaptProvider(p IProvider) IJob {
+	return &providerJob{p: p}
+}
+
+func (j *providerJob) Init() error {
+	return j.p.Init()
+}
+
+func (j *providerJob) GetName() string {
+	return j.p.GetName()
+}
+
+func (j *providerJob) CheckInterval(t time.Time) bool {
+	return j.p.CheckInterval(t)
+}
+
+func (j *providerJob) Run(_ context.Context, t time.Time) error {
+	j.p.Run(t)
+	return nil
+}
+
+func (j *providerJob) String() string {
+	return j.p.String()
+}
+
+func (w *Worker) runPolicy() RunPolicy {
+	if r, ok := w.origin.(IRunPolicy); ok {
+		return r.RunPolicy()
+	}
+
+	return RunPolicy{}
+}
+
+// execute runs a firing with the retries of the run policy and records it in the history.
+func (w *Worker) execute(p *Point) (err error) {
+	name, policy := w.job.GetName(), w.runPolicy()
+	retryInterval := policy.RetryInterval
+	e := Execution{Firing: p.t, Signal: p.signal, Start: time.Now()}
+
+	for {
+		e.Attempts++
+
+		if err = w.attempt(p.t, policy.Timeout); err == nil || e.Attempts > policy.Retry {
+			break
+		}
+
+		w.logger.Warningf("[%s] run failed, retry in %s | firing: %s | attempt: %d | error: %s", name, retryInterval, p.t.Format(SignalFormat), e.Attempts, err)
+
+		select {
+		case <-w.ctx.Done():
+		case <-time.After(retryInterval):
+			retryInterval *= 2
+			continue
+		}
+
+		break
+	}
+
+	e.Duration = time.Since(e.Start)
+	e.Result = result(err)
+	e.Running = w.running != nil
+
+	if err != nil {
+		e.Error = err.Error()
+		w.logger.Errorf("[%s] run failed | firing: %s | attempts: %d | error: %s", name, p.t.Format(SignalFormat), e.Attempts, err)
+	}
+
+	seq := w.history.add(e)
+
+	if running := w.running; running != nil {
+		go func() {
+			<-running
+			w.history.finish(seq)
+		}()
+	}
+
+	return
+}
+
+// wait blocks until the run left behind by a timeout returns, so a job never runs twice at once;
+// it gives up when the master stops.
+func (w *Worker) wait() bool {
+	if w.running == nil {
+		return true
+	}
+
+	select {
+	case <-w.running:
+	default:
+		w.logger.Warningf("[%s] wait for the timed out run to return", w.job.GetName())
+
+		select {
+		case <-w.running:
+		case <-w.ctx.Done():
+			return false
+		}
+	}
+
+	w.running = nil
+	return true
+}
+
+// attempt runs the job once. On timeout it returns ErrTimeout at once, a job that ignores its
+// context keeps running and the next attempt, retry or firing, waits for it.
+func (w *Worker) attempt(t time.Time, timeout time.Duration) error {
+	if !w.wait() {
+		return w.ctx.Err()
+	}
+
+	if timeout <= 0 {
+		return w.call(w.ctx, t)
+	}
+
+	ctx, cancel := context.WithTimeout(w.ctx, timeout)
+	finished := make(chan bool)
+	var err error
+
+	go func() {
+		defer cancel()
+
+		err = w.call(ctx, t)
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return err
+	case <-ctx.Done():
+	}
+
+	if ctx.Err() != context.DeadlineExceeded {
+		// the master is stopping, let the job finish
+		<-finished
+		return err
+	}
+
+	w.running = finished
+	return ErrTimeout
+}
+
+func (w *Worker) call(ctx context.Context, t time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			err = &PanicError{Value: r, Stack: stack}
+			w.logger.Logw(logger.ErrorLevel, "catch panic", "provider", w.job.GetName(), "panic", r, "stack", string(stack))
+		}
+	}()
+
+	return w.job.Run(ctx, t)
+}
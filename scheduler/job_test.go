@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marsmay/golib/logger"
+)
+
+type testJob struct {
+	policy  RunPolicy
+	run     func(ctx context.Context, n int32) error
+	calls   int32
+	active  int32
+	overlap int32
+}
+
+func (j *testJob) Init() error {
+	return nil
+}
+
+func (j *testJob) GetName() string {
+	return "test"
+}
+
+func (j *testJob) CheckInterval(time.Time) bool {
+	return false
+}
+
+func (j *testJob) Run(ctx context.Context, _ time.Time) error {
+	if atomic.AddInt32(&j.active, 1) > 1 {
+		atomic.StoreInt32(&j.overlap, 1)
+	}
+
+	defer atomic.AddInt32(&j.active, -1)
+
+	return j.run(ctx, atomic.AddInt32(&j.calls, 1))
+}
+
+func (j *testJob) String() string {
+	return j.GetName()
+}
+
+func (j *testJob) RunPolicy() RunPolicy {
+	return j.policy
+}
+
+func newTestWorker(t *testing.T, j IJob) *Worker {
+	conf := logger.DefaultConfig()
+	conf.Terminal, conf.Dir = false, t.TempDir()
+
+	l, err := logger.NewLogger(conf)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = l.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	w, err := NewJobWorker(j, l, ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+// sleepy ignores its context the first time, so its first attempt outlives the timeout.
+func sleepy(ctx context.Context, n int32) error {
+	if n == 1 {
+		time.Sleep(150 * time.Millisecond)
+		return nil
+	}
+
+	return ctx.Err()
+}
+
+func TestTimeoutRetryDoesNotOverlap(t *testing.T) {
+	j := &testJob{policy: RunPolicy{Timeout: 50 * time.Millisecond, Retry: 1, RetryInterval: 10 * time.Millisecond}, run: sleepy}
+	w := newTestWorker(t, j)
+
+	if err := w.execute(&Point{t: time.Now()}); err != nil {
+		t.Fatalf("execute returned %v, want the retry to succeed", err)
+	}
+
+	if calls := atomic.LoadInt32(&j.calls); calls != 2 {
+		t.Errorf("job ran %d times, want 2", calls)
+	}
+
+	if atomic.LoadInt32(&j.overlap) != 0 {
+		t.Error("the retry ran while the timed out attempt was still running")
+	}
+
+	records := w.history.list()
+
+	if len(records) != 1 || records[0].Attempts != 2 || records[0].Result != ResultSuccess || records[0].Running {
+		t.Errorf("unexpected history %+v", records)
+	}
+}
+
+func TestTimeoutNextFiringDoesNotOverlap(t *testing.T) {
+	j := &testJob{policy: RunPolicy{Timeout: 50 * time.Millisecond}, run: sleepy}
+	w := newTestWorker(t, j)
+
+	if err := w.execute(&Point{t: time.Now()}); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("execute returned %v, want ErrTimeout", err)
+	}
+
+	if records := w.history.list(); len(records) != 1 || records[0].Result != ResultTimeout || !records[0].Running {
+		t.Fatalf("unexpected history %+v", records)
+	}
+
+	if err := w.execute(&Point{t: time.Now()}); err != nil {
+		t.Fatalf("execute returned %v, want nil", err)
+	}
+
+	if atomic.LoadInt32(&j.overlap) != 0 {
+		t.Error("the next firing ran while the timed out one was still running")
+	}
+
+	// the timed out execution is marked finished by a goroutine of its own
+	for deadline := time.Now().Add(time.Second); w.history.list()[0].Running && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	if records := w.history.list(); len(records) != 2 || records[0].Running || records[1].Result != ResultSuccess {
+		t.Errorf("unexpected history %+v", records)
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	j := &testJob{run: func(context.Context, int32) error { panic("boom") }}
+	w := newTestWorker(t, j)
+
+	err := w.execute(&Point{t: time.Now()})
+
+	var pe *PanicError
+
+	if !errors.As(err, &pe) || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Fatalf("execute returned %v, want a PanicError with a stack", err)
+	}
+
+	if records := w.history.list(); len(records) != 1 || records[0].Result != ResultPanic || records[0].Error != "panic: boom" {
+		t.Errorf("unexpected history %+v", records)
+	}
+}
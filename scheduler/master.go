@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/marsmay/golib/logger"
 )
@@ -37,19 +38,80 @@ func (m *Master) SendSign(provider, signal string) error {
 	return worker.SendSign(signal)
 }
 
+// UseCoordinator makes every scheduled firing run on one instance only, it must be called before Start.
+func (m *Master) UseCoordinator(c *Coordinator) {
+	for _, worker := range m.workers {
+		worker.coordinator = c
+	}
+}
+
+// UseStore keeps the last successful run of every provider in s, so missed firings can be caught up
+// after a restart according to each provider's misfire policy. It must be called before Start.
+func (m *Master) UseStore(s IStore) {
+	for _, worker := range m.workers {
+		worker.store = s
+	}
+}
+
+// NextRuns lists up to n upcoming firings of every provider that can tell them, see ISchedule.
+func (m *Master) NextRuns(after time.Time, n int) map[string][]time.Time {
+	runs := make(map[string][]time.Time, len(m.workers))
+
+	for name, worker := range m.workers {
+		if s, ok := worker.origin.(ISchedule); ok {
+			runs[name] = s.NextN(after, n)
+		}
+	}
+
+	return runs
+}
+
+// History lists the recent executions of a provider, oldest first.
+func (m *Master) History(provider string) ([]Execution, error) {
+	worker, ok := m.workers[provider]
+
+	if !ok {
+		return nil, fmt.Errorf("provider '%s' does not exist", provider)
+	}
+
+	return worker.history.list(), nil
+}
+
+// Histories lists the recent executions of every provider.
+func (m *Master) Histories() map[string][]Execution {
+	histories := make(map[string][]Execution, len(m.workers))
+
+	for name, worker := range m.workers {
+		histories[name] = worker.history.list()
+	}
+
+	return histories
+}
+
 func NewMaster(providers []IProvider, l *logger.Logger) *Master {
-	master := &Master{workers: make(map[string]*Worker, len(providers))}
-	master.baseCtx, master.stopFunc = context.WithCancel(context.Background())
+	jobs := make([]IJob, 0, len(providers))
 
 	for _, p := range providers {
-		worker, err := NewWorker(p, l, master.baseCtx)
+		jobs = append(jobs, AdaptProvider(p))
+	}
+
+	return NewJobMaster(jobs, l)
+}
+
+// NewJobMaster schedules jobs with the context-aware contract, use AdaptProvider to mix in providers.
+func NewJobMaster(jobs []IJob, l *logger.Logger) *Master {
+	master := &Master{workers: make(map[string]*Worker, len(jobs))}
+	master.baseCtx, master.stopFunc = context.WithCancel(context.Background())
+
+	for _, j := range jobs {
+		worker, err := NewJobWorker(j, l, master.baseCtx)
 
 		if err != nil {
-			l.Errorf("[%s] init failed | error: %s", p.GetName(), err)
+			l.Errorf("[%s] init failed | error: %s", j.GetName(), err)
 			continue
 		}
 
-		master.workers[worker.provider.GetName()] = worker
+		master.workers[worker.job.GetName()] = worker
 	}
 
 	return master
@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"time"
+)
+
+// nextYears bounds the search of Next, a rule like "0 0 29 2 *" can wait 8 years for a leap day.
+const nextYears = 10
+
+func (o *Interval) nextEvery(after time.Time) time.Time {
+	every := int64(o.every / time.Second)
+	return time.Unix((after.Unix()/every+1)*every, 0).In(after.Location())
+}
+
+func matchAll(set map[int]bool, v int) bool {
+	return len(set) == 0 || set[v]
+}
+
+// repeated reports whether the wall clock of t was already seen an hour earlier, in the hour
+// repeated when daylight saving time ends.
+func repeated(t time.Time) bool {
+	prev := t.Add(-time.Hour)
+	return prev.Hour() == t.Hour() && prev.Minute() == t.Minute() && prev.Day() == t.Day()
+}
+
+// Next returns the first time after after that matches, in the location of after, or the zero time
+// when the rule never matches, e.g. "0 0 30 2 *". Fields are searched from the month down and time is
+// advanced in real hours, so a time skipped by a daylight saving change doesn't fire that day and a rule
+// with fixed hours fires once in a repeated hour.
+func (o *Interval) Next(after time.Time) time.Time {
+	if o.every > 0 {
+		return o.nextEvery(after)
+	}
+
+	loc := after.Location()
+	step := time.Minute
+
+	if len(o.seconds) != 0 {
+		step = time.Second
+	}
+
+	t := after.Truncate(step).Add(step)
+	limit := t.Year() + nextYears
+
+wrap:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+
+	for !matchAll(o.months, int(t.Month())) {
+		t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc).AddDate(0, 1, 0)
+
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !o.checkDay(t) {
+		month := t.Month()
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+
+		if t.Month() != month {
+			goto wrap
+		}
+	}
+
+	for !matchAll(o.hours, t.Hour()) {
+		day := t.Day()
+		// step back by the wall clock minutes, Truncate would be off in zones with half hour offsets
+		t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second)
+
+		if t.Day() != day {
+			goto wrap
+		}
+	}
+
+	for !matchAll(o.minutes, t.Minute()) {
+		hour := t.Hour()
+		t = t.Truncate(time.Minute).Add(time.Minute)
+
+		if t.Hour() != hour {
+			goto wrap
+		}
+	}
+
+	for !matchAll(o.seconds, t.Second()) {
+		minute := t.Minute()
+		t = t.Add(time.Second)
+
+		if t.Minute() != minute {
+			goto wrap
+		}
+	}
+
+	if len(o.hours) < 24 && repeated(t) {
+		t = t.Add(step)
+		goto wrap
+	}
+
+	return t
+}
+
+// NextN returns up to n next times after after, fewer when the rule stops matching.
+func (o *Interval) NextN(after time.Time, n int) []time.Time {
+	times := make([]time.Time, 0, n)
+
+	for i := 0; i < n; i++ {
+		if after = o.Next(after); after.IsZero() {
+			break
+		}
+
+		times = append(times, after)
+	}
+
+	return times
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestNextN(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, ny)
+	}
+
+	utc := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	// 2026-03-08 02:00 EST jumps to 03:00 EDT, 2026-11-01 02:00 EDT falls back to 01:00 EST
+	tests := []struct {
+		name  string
+		rule  string
+		after time.Time
+		want  []time.Time
+	}{
+		{"every minute", "* * * * *", at(2026, 1, 1, 10, 0), []time.Time{at(2026, 1, 1, 10, 1), at(2026, 1, 1, 10, 2)}},
+		{"spring forward skips the gap", "30 2 * * *", at(2026, 3, 7, 12, 0), []time.Time{at(2026, 3, 9, 2, 30), at(2026, 3, 10, 2, 30)}},
+		{"spring forward hourly", "0 * * * *", at(2026, 3, 8, 0, 30), []time.Time{at(2026, 3, 8, 1, 0), at(2026, 3, 8, 3, 0), at(2026, 3, 8, 4, 0)}},
+		{"fall back fires once", "30 1 * * *", at(2026, 10, 31, 12, 0), []time.Time{at(2026, 11, 1, 1, 30), at(2026, 11, 2, 1, 30)}},
+		{"fall back hourly", "0 * * * *", at(2026, 11, 1, 0, 30), []time.Time{utc(11, 1, 5), utc(11, 1, 6), utc(11, 1, 7)}},
+		{"leap day", "0 0 29 2 *", at(2026, 3, 1, 0, 0), []time.Time{at(2028, 2, 29, 0, 0), at(2032, 2, 29, 0, 0)}},
+		{"impossible day", "0 0 30 2 *", at(2026, 1, 1, 0, 0), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := NewInterval(tt.rule)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := o.NextN(tt.after, len(tt.want)+1)
+
+			if len(tt.want) == 0 {
+				if len(got) != 0 {
+					t.Fatalf("NextN returned %v, want none", got)
+				}
+
+				if next := o.Next(tt.after); !next.IsZero() {
+					t.Fatalf("Next returned %s, want the zero time", next)
+				}
+
+				return
+			}
+
+			if len(got) < len(tt.want) {
+				t.Fatalf("NextN returned %v, want %v first", got, tt.want)
+			}
+
+			for i, want := range tt.want {
+				if !got[i].Equal(want) {
+					t.Errorf("time %d is %s, want %s", i, got[i], want.In(ny))
+				}
+
+				if got[i].Location() != ny {
+					t.Errorf("time %d is in %s, want %s", i, got[i].Location(), ny)
+				}
+			}
+		})
+	}
+}
@@ -2,11 +2,21 @@ package scheduler
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
 const SignalFormat = "20060102150405"
 
+const defaultRetryInterval = 1000
+
+// Misfire policies decide what happens to firings missed while the worker was busy or the process was down.
+const (
+	MisfireSkip = "skip"
+	MisfireOnce = "once"
+	MisfireAll  = "all"
+)
+
 type IProvider interface {
 	Init() error
 	GetName() string
@@ -15,17 +25,59 @@ type IProvider interface {
 	String() string
 }
 
+// ISchedule is implemented by providers that can tell their next firings, such as Provider.
+// Workers use it to wake up exactly on time, other providers are checked once a minute.
+type ISchedule interface {
+	Next(after time.Time) time.Time
+	NextN(after time.Time, n int) []time.Time
+}
+
+// IMisfire is implemented by providers with a misfire policy, such as Provider; others skip missed firings.
+type IMisfire interface {
+	MisfirePolicy() string
+}
+
+// Provider is the base of providers and jobs, Timeout and RetryInterval are in milliseconds.
 type Provider struct {
-	Name     string    `toml:"name" json:"name"`
-	TimeRule string    `toml:"interval" json:"interval"`
-	Interval *Interval `toml:"-" json:"-"`
+	Name          string        `toml:"name" json:"name"`
+	TimeRule      string        `toml:"interval" json:"interval"`
+	Misfire       string        `toml:"misfire" json:"misfire"`
+	Timeout       time.Duration `toml:"timeout" json:"timeout"`
+	Retry         int           `toml:"retry" json:"retry"`
+	RetryInterval time.Duration `toml:"retry_interval" json:"retry_interval"`
+	Interval      *Interval     `toml:"-" json:"-"`
 }
 
 func (p *Provider) Init() (err error) {
+	switch p.Misfire {
+	case "", MisfireSkip, MisfireOnce, MisfireAll:
+	default:
+		return fmt.Errorf("invalid misfire policy '%s'", p.Misfire)
+	}
+
 	p.Interval, err = NewInterval(p.TimeRule)
 	return
 }
 
+// MisfirePolicy returns skip, once or all, skip when unset.
+func (p *Provider) MisfirePolicy() string {
+	if p.Misfire == "" {
+		return MisfireSkip
+	}
+
+	return p.Misfire
+}
+
+func (p *Provider) RunPolicy() RunPolicy {
+	retryInterval := p.RetryInterval
+
+	if retryInterval <= 0 {
+		retryInterval = defaultRetryInterval
+	}
+
+	return RunPolicy{Timeout: p.Timeout * time.Millisecond, Retry: p.Retry, RetryInterval: retryInterval * time.Millisecond}
+}
+
 func (p *Provider) GetName() string {
 	return p.Name
 }
@@ -38,6 +90,23 @@ func (p *Provider) CheckInterval(t time.Time) bool {
 	return p.Interval.Check(t)
 }
 
+// Next returns the first firing after after, the zero time if the rule never fires.
+func (p *Provider) Next(after time.Time) time.Time {
+	if p.Interval == nil {
+		return time.Time{}
+	}
+
+	return p.Interval.Next(after)
+}
+
+func (p *Provider) NextN(after time.Time, n int) []time.Time {
+	if p.Interval == nil {
+		return nil
+	}
+
+	return p.Interval.NextN(after, n)
+}
+
 func (p *Provider) GetSignal(t time.Time) string {
 	return t.Format(SignalFormat)
 }
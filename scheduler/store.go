@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IStore keeps the time of the last successful scheduled run of each provider, so a worker can
+// catch up on the firings missed while the process was down. LastRun returns the zero time if unknown.
+type IStore interface {
+	LastRun(provider string) (time.Time, error)
+	SetLastRun(provider string, t time.Time) error
+}
+
+// FileStore keeps the last runs in a JSON file, rewritten atomically on every change.
+type FileStore struct {
+	file   string
+	locker sync.Mutex
+	runs   map[string]time.Time
+}
+
+func NewFileStore(file string) (s *FileStore, err error) {
+	s = &FileStore{file: file, runs: make(map[string]time.Time)}
+	data, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+
+		return
+	}
+
+	err = json.Unmarshal(data, &s.runs)
+	return
+}
+
+func (s *FileStore) LastRun(provider string) (time.Time, error) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	return s.runs[provider], nil
+}
+
+func (s *FileStore) SetLastRun(provider string, t time.Time) (err error) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+
+	s.runs[provider] = t
+	data, err := json.MarshalIndent(s.runs, "", "  ")
+
+	if err != nil {
+		return
+	}
+
+	tmpFile := s.file + ".tmp"
+
+	if err = ioutil.WriteFile(tmpFile, data, 0644); err != nil {
+		return
+	}
+
+	return os.Rename(tmpFile, s.file)
+}
+
+// RedisStore keeps the last runs as unix seconds in one hash, field per provider.
+type RedisStore struct {
+	client redis.Cmdable
+	key    string
+}
+
+func NewRedisStore(client redis.Cmdable, key string) *RedisStore {
+	return &RedisStore{client: client, key: key}
+}
+
+func (s *RedisStore) LastRun(provider string) (t time.Time, err error) {
+	value, err := s.client.HGet(s.key, provider).Result()
+
+	if err != nil {
+		if err == redis.Nil {
+			err = nil
+		}
+
+		return
+	}
+
+	sec, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil {
+		return
+	}
+
+	return time.Unix(sec, 0), nil
+}
+
+func (s *RedisStore) SetLastRun(provider string, t time.Time) error {
+	return s.client.HSet(s.key, provider, t.Unix()).Err()
+}
+
+type LastRun struct {
+	Provider  string    `gorm:"primaryKey;size:128"`
+	RunAt     time.Time `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// GormStore keeps the last runs in a table, one row per provider; NewGormStore creates the table if needed.
+type GormStore struct {
+	db    *gorm.DB
+	table string
+}
+
+func NewGormStore(db *gorm.DB, table string) (s *GormStore, err error) {
+	s = &GormStore{db: db, table: table}
+	err = db.Table(table).AutoMigrate(&LastRun{})
+	return
+}
+
+func (s *GormStore) LastRun(provider string) (t time.Time, err error) {
+	var rows []*LastRun
+
+	if err = s.db.Table(s.table).Where("provider = ?", provider).Limit(1).Find(&rows).Error; err != nil || len(rows) == 0 {
+		return
+	}
+
+	return rows[0].RunAt, nil
+}
+
+func (s *GormStore) SetLastRun(provider string, t time.Time) error {
+	return s.db.Table(s.table).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}},
+		DoUpdates: clause.AssignmentColumns([]string{"run_at", "updated_at"}),
+	}).Create(&LastRun{Provider: provider, RunAt: t}).Error
+}
@@ -9,39 +9,58 @@ import (
 	"github.com/marsmay/golib/logger"
 )
 
+// maxMissed bounds the firings kept to run late under the "all" misfire policy.
+const maxMissed = 1000
+
 type Point struct {
-	signal bool
-	t      time.Time
+	signal  bool
+	t       time.Time
+	claimed bool
+	missed  bool
 }
 
 type Worker struct {
-	provider  IProvider
-	logger    *logger.Logger
-	ctx       context.Context
-	loopTimer chan *Point
-	endSign   chan bool
+	job         IJob
+	origin      interface{}
+	history     *history
+	logger      *logger.Logger
+	coordinator *Coordinator
+	store       IStore
+	lastRun     time.Time
+	running     chan bool
+	fireCh      chan *Point
+	ctx         context.Context
+	loopTimer   chan *Point
+	endSign     chan bool
 }
 
 func (w *Worker) SendSign(signal string) (err error) {
 	signTime, e := time.ParseInLocation(SignalFormat, strings.TrimSpace(signal), time.Local)
 
 	if e != nil {
-		return fmt.Errorf("[%s] signal fotmat error: '%s'", w.provider.GetName(), signal)
+		return fmt.Errorf("[%s] signal fotmat error: '%s'", w.job.GetName(), signal)
 	}
 
 	select {
 	case <-w.ctx.Done():
-		err = fmt.Errorf("[%s] worker is closed", w.provider.GetName())
-	case w.loopTimer <- &Point{true, signTime}:
-		w.logger.Infof("[%s] receive signal: %s", w.provider.GetName(), signal)
+		err = fmt.Errorf("[%s] worker is closed", w.job.GetName())
+	case w.loopTimer <- &Point{signal: true, t: signTime}:
+		w.logger.Infof("[%s] receive signal: %s", w.job.GetName(), signal)
 	default:
-		err = fmt.Errorf("[%s] worker is busy", w.provider.GetName())
+		err = fmt.Errorf("[%s] worker is busy", w.job.GetName())
 	}
 
 	return
 }
 
 func (w *Worker) startLoop() {
+	defer logger.RecoverAndLog(w.logger, true)
+
+	if s, ok := w.origin.(ISchedule); ok {
+		w.scheduleLoop(s)
+		return
+	}
+
 	if second := time.Now().Second(); second > 0 {
 		time.Sleep(time.Minute - time.Duration(second)*time.Second)
 	}
@@ -54,20 +73,221 @@ func (w *Worker) startLoop() {
 		case <-w.ctx.Done():
 			return
 		case t := <-ticker.C:
-			if w.provider.CheckInterval(t) {
-				w.loopTimer <- &Point{false, t}
+			if w.job.CheckInterval(t) {
+				w.loopTimer <- &Point{t: t}
+			}
+		}
+	}
+}
+
+func (w *Worker) misfirePolicy() string {
+	if m, ok := w.origin.(IMisfire); ok {
+		return m.MisfirePolicy()
+	}
+
+	return MisfireSkip
+}
+
+// misfire adds a firing that couldn't start on time to the ones waiting for the worker.
+func (w *Worker) misfire(policy string, pending []time.Time, t time.Time) []time.Time {
+	name := w.job.GetName()
+
+	switch policy {
+	case MisfireOnce:
+		if len(pending) > 0 {
+			w.logger.Warningf("[%s] skip missed firing | firing: %s", name, pending[0].Format(SignalFormat))
+		}
+
+		return []time.Time{t}
+	case MisfireAll:
+		if len(pending) >= maxMissed {
+			w.logger.Warningf("[%s] too many missed firings, skip | firing: %s", name, pending[0].Format(SignalFormat))
+			pending = pending[1:]
+		}
+
+		return append(pending, t)
+	default:
+		w.logger.Warningf("[%s] skip missed firing | firing: %s", name, t.Format(SignalFormat))
+		return pending
+	}
+}
+
+// start finds where the schedule resumes: the firings since the last successful run recorded in
+// the store are missed ones, unless the policy skips them.
+func (w *Worker) start(s ISchedule, policy string) (last time.Time, pending []time.Time) {
+	name, now := w.job.GetName(), time.Now()
+
+	if w.store == nil {
+		return now, nil
+	}
+
+	lastRun, err := w.store.LastRun(name)
+
+	if err != nil {
+		w.logger.Errorf("[%s] load last run failed | error: %s", name, err)
+		return now, nil
+	}
+
+	if lastRun.IsZero() {
+		return now, nil
+	}
+
+	skipped := 0
+
+	for t := s.Next(lastRun.In(now.Location())); !t.IsZero() && !t.After(now); t = s.Next(t) {
+		switch policy {
+		case MisfireOnce:
+			if len(pending) > 0 {
+				skipped++
 			}
+
+			pending = []time.Time{t}
+		case MisfireAll:
+			if len(pending) >= maxMissed {
+				skipped++
+				pending = pending[1:]
+			}
+
+			pending = append(pending, t)
+		default:
+			skipped++
 		}
 	}
+
+	if skipped > 0 {
+		w.logger.Warningf("[%s] skip %d firings missed since last run | last run: %s", name, skipped, lastRun.Format(SignalFormat))
+	}
+
+	if len(pending) > 0 {
+		w.logger.Infof("[%s] catch up %d firings missed since last run | last run: %s", name, len(pending), lastRun.Format(SignalFormat))
+	}
+
+	return now, pending
+}
+
+// scheduleLoop sleeps until each next firing of the provider instead of polling every minute.
+// A firing that comes while the worker is still busy is a misfire, handled by the provider's policy.
+func (w *Worker) scheduleLoop(s ISchedule) {
+	policy := w.misfirePolicy()
+	last, pending := w.start(s, policy)
+
+	for {
+		next := s.Next(last)
+
+		if next.IsZero() && len(pending) == 0 {
+			w.logger.Warningf("[%s] interval never fires", w.job.GetName())
+			return
+		}
+
+		var timer *time.Timer
+		var timerCh <-chan time.Time
+
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			timerCh = timer.C
+		}
+
+		// a nil channel disables the case while nothing is pending
+		var fireCh chan *Point
+		var missed *Point
+
+		if len(pending) > 0 {
+			fireCh, missed = w.fireCh, &Point{t: pending[0], missed: true}
+		}
+
+		select {
+		case <-w.ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+
+			return
+		case fireCh <- missed:
+			pending = pending[1:]
+
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-timerCh:
+			last = next
+
+			select {
+			case w.fireCh <- &Point{t: next}:
+			default:
+				pending = w.misfire(policy, pending, next)
+			}
+		}
+	}
+}
+
+// run executes a firing. With a coordinator a scheduled firing only runs on the instance that
+// claims it, a signal always runs locally.
+func (w *Worker) run(p *Point) {
+	name := w.job.GetName()
+
+	if w.coordinator == nil || p.signal {
+		if w.execute(p) == nil {
+			w.saveLastRun(p)
+		}
+
+		return
+	}
+
+	if !p.claimed {
+		ok, err := w.coordinator.claim(name, p.t)
+
+		if err != nil {
+			w.logger.Errorf("[%s] claim firing failed, skip | firing: %s | error: %s", name, p.t.Format(SignalFormat), err)
+			return
+		}
+
+		if !ok {
+			w.logger.Infof("[%s] firing claimed by another instance, skip | firing: %s", name, p.t.Format(SignalFormat))
+			w.coordinator.watch(w.ctx, name, p.t, func() { w.takeover(p.t) })
+
+			// the firing is handled elsewhere, a local store must still move on or a restart
+			// would catch up on it once the done marker is pruned
+			w.saveLastRun(p)
+			return
+		}
+	}
+
+	err := w.execute(p)
+	w.coordinator.done(name, p.t)
+
+	if err == nil {
+		w.saveLastRun(p)
+	}
+}
+
+// saveLastRun only moves the last run forward, a late takeover of an older firing leaves it alone.
+func (w *Worker) saveLastRun(p *Point) {
+	if w.store == nil || p.signal || !p.t.After(w.lastRun) {
+		return
+	}
+
+	w.lastRun = p.t
+
+	if err := w.store.SetLastRun(w.job.GetName(), p.t); err != nil {
+		w.logger.Errorf("[%s] save last run failed | firing: %s | error: %s", w.job.GetName(), p.t.Format(SignalFormat), err)
+	}
+}
+
+func (w *Worker) takeover(t time.Time) {
+	select {
+	case <-w.ctx.Done():
+	case w.loopTimer <- &Point{t: t, claimed: true}:
+	}
 }
 
 func (w *Worker) Run() {
 	defer func() {
-		w.logger.Infof("[%s] worker end", w.provider.GetName())
+		w.logger.Infof("[%s] worker end", w.job.GetName())
 		w.endSign <- true
 	}()
+	defer logger.RecoverAndLog(w.logger, true)
 
-	w.logger.Infof("[%s] worker start", w.provider.GetName())
+	w.logger.Infof("[%s] worker start", w.job.GetName())
 	go w.startLoop()
 
 	for {
@@ -76,10 +296,16 @@ func (w *Worker) Run() {
 			return
 		case p := <-w.loopTimer:
 			if p.signal {
-				w.logger.Infof("[%s] run by signal", w.provider.GetName())
+				w.logger.Infof("[%s] run by signal", w.job.GetName())
+			}
+
+			w.run(p)
+		case p := <-w.fireCh:
+			if p.missed {
+				w.logger.Warningf("[%s] run missed firing | firing: %s", w.job.GetName(), p.t.Format(SignalFormat))
 			}
 
-			w.provider.Run(p.t)
+			w.run(p)
 		}
 	}
 }
@@ -89,15 +315,29 @@ func (w *Worker) Done() {
 }
 
 func NewWorker(p IProvider, l *logger.Logger, ctx context.Context) (worker *Worker, err error) {
-	if err = p.Init(); err != nil {
+	return NewJobWorker(AdaptProvider(p), l, ctx)
+}
+
+func NewJobWorker(j IJob, l *logger.Logger, ctx context.Context) (worker *Worker, err error) {
+	if err = j.Init(); err != nil {
 		return
 	}
 
+	// optional interfaces such as ISchedule are looked up on the provider behind an adapter
+	var origin interface{} = j
+
+	if pj, ok := j.(*providerJob); ok {
+		origin = pj.p
+	}
+
 	worker = &Worker{
-		provider:  p,
+		job:       j,
+		origin:    origin,
+		history:   newHistory(HistorySize),
 		logger:    l,
 		ctx:       ctx,
 		loopTimer: make(chan *Point, 1),
+		fireCh:    make(chan *Point),
 		endSign:   make(chan bool, 1),
 	}
 	return
@@ -19,8 +19,17 @@ func IsTraceId(traceId string) bool {
 	return err == nil
 }
 
-func GetTraceId(ctx context.Context) (traceId string) {
+// LookupTraceId returns the trace id carried by ctx, it does not generate one when missing.
+func LookupTraceId(ctx context.Context) (traceId string, ok bool) {
 	if v, ok := ctx.Value(ContextKeyTraceId).(string); ok && v != "" && IsTraceId(v) {
+		return v, true
+	}
+
+	return "", false
+}
+
+func GetTraceId(ctx context.Context) (traceId string) {
+	if v, ok := LookupTraceId(ctx); ok {
 		return v
 	}
 
@@ -53,6 +53,7 @@ func (c *Client) WatchNode(path string, eventType zk.EventType, callback func(zk
 
 	go func() {
 		defer c.wg.Done()
+		defer logger.RecoverAndLog(c.logger, true)
 
 		for {
 			select {
@@ -67,7 +68,7 @@ func (c *Client) WatchNode(path string, eventType zk.EventType, callback func(zk
 				}
 
 				if !exists {
-					callback(zk.Event{Type: zk.EventNodeDeleted, State: zk.StateUnknown, Path: path})
+					logger.Protect(c.logger, func() { callback(zk.Event{Type: zk.EventNodeDeleted, State: zk.StateUnknown, Path: path}) })
 					continue
 				}
 
@@ -82,7 +83,7 @@ func (c *Client) WatchNode(path string, eventType zk.EventType, callback func(zk
 				}
 
 				if eventType == EventTypeAll || eventType == event.Type {
-					callback(event)
+					logger.Protect(c.logger, func() { callback(event) })
 				}
 			}
 		}
@@ -94,6 +95,7 @@ func (c *Client) WatchChildren(path string, eventType zk.EventType, callback fun
 
 	go func() {
 		defer c.wg.Done()
+		defer logger.RecoverAndLog(c.logger, true)
 
 		for {
 			select {
@@ -121,7 +123,7 @@ func (c *Client) WatchChildren(path string, eventType zk.EventType, callback fun
 				}
 
 				if eventType == EventTypeAll || eventType == event.Type {
-					callback(event)
+					logger.Protect(c.logger, func() { callback(event) })
 				}
 			}
 		}